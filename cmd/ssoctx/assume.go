@@ -1,8 +1,6 @@
 package main
 
 import (
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/spf13/cobra"
 
 	"ssoctx/internal/amazon"
@@ -22,16 +20,7 @@ var assumeCmd = &cobra.Command{
 		startURL = conf.StartURL
 		region = conf.Region
 
-		cfg, err := config.LoadDefaultConfig(ctx,
-			config.WithRegion(region),
-			config.WithCredentialsProvider(aws.AnonymousCredentials{}),
-		)
-		if err != nil {
-			logger.Fatal().Msgf("Encountered error in loading default aws config: %v", err)
-		}
-		oidcClient, ssoClient := amazon.NewClients(cfg)
-		oidc := amazon.NewOIDCClient(oidcClient, startURL)
-		sso := amazon.NewSSOClient(ssoClient)
+		oidc, sso := newClients(logger, conf)
 
 		amazon.AssumeCredentialProcess(ctx, oidc, sso, amazon.AssumeFlagInputs{
 			AccountID: accountID,
@@ -39,6 +28,7 @@ var assumeCmd = &cobra.Command{
 			Profile:   profile,
 			StartURL:  startURL,
 			Region:    region,
+			Chain:     roleChain(logger, conf),
 		})
 	},
 }
@@ -50,6 +40,7 @@ func init() {
 	assumeCmd.Flags().StringVarP(&profile, "profile", "p", "default", "the profile name to set in credentials file")
 	assumeCmd.Flags().StringVarP(&accountID, "account-id", "a", "", "set account id for desired aws account")
 	assumeCmd.Flags().StringVarP(&roleName, "role-name", "n", "", "set / override with permission set role name")
+	assumeCmd.Flags().StringVarP(&chainName, "chain", "", "", "set a role chain from config to assume on top of the SSO role")
 	assumeCmd.Flags().BoolVarP(&debug, "debug", "", false, "toggle if you want to enable debug logs")
 	assumeCmd.Flags().BoolVarP(&jsonFormat, "json", "", false, "toggle if you want to enable json log output")
 }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/rs/zerolog"
+
+	"ssoctx/internal/amazon"
+	"ssoctx/internal/file"
+)
+
+// newClients loads the aws config for the region and returns the sso oidc and sso clients
+// The sso client uses the account and role catalog and the selection history of the start url
+func newClients(logger zerolog.Logger, conf *file.AppConfig) (*amazon.OIDCClientAPI, *amazon.Client) {
+	cfg, err := config.LoadDefaultConfig(ctx,
+		config.WithRegion(region),
+		config.WithCredentialsProvider(aws.AnonymousCredentials{}),
+	)
+	if err != nil {
+		logger.Fatal().Msgf("Encountered error in loading default aws config: %v", err)
+	}
+	oidcClient, ssoClient := amazon.NewClients(cfg)
+	sso := amazon.NewSSOClient(ssoClient)
+	sso.UseCatalog(amazon.CatalogOptions{
+		StartURL: startURL,
+		TTL:      conf.CatalogTTL,
+		Refresh:  refreshCatalog,
+	})
+	sso.UseHistory(startURL, conf.Favourites)
+	sso.UsePicker(pickerCommand(conf))
+	sso.UseAccountMetadata(conf.AccountMetadata(ctx), conf.GroupByEnv)
+	sso.UseSafeguards(conf.Safeguards)
+	sso.UsePolicy(file.ReadPolicy(ctx))
+	sso.UseRolePreference(conf.RolePreference, pickRole)
+	oidc := amazon.NewOIDCClient(oidcClient, startURL)
+	sso.UseReauthentication(oidc)
+	return oidc, sso
+}
+
+// pickerCommand returns the external picker set with --picker, otherwise the one from config
+func pickerCommand(conf *file.AppConfig) string {
+	if len(picker) > 0 {
+		return picker
+	}
+	return conf.Picker
+}
+
+// roleChain returns the role chain set with --chain or nil when not set
+func roleChain(logger zerolog.Logger, conf *file.AppConfig) *file.RoleChain {
+	if len(chainName) == 0 {
+		return nil
+	}
+	chain, err := conf.Chain(chainName)
+	if err != nil {
+		logger.Fatal().Msgf("Encountered error finding role chain: %v", err)
+	}
+	return chain
+}
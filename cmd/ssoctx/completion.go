@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/spf13/cobra"
+
+	"ssoctx/internal/amazon"
+	"ssoctx/internal/file"
+	"ssoctx/internal/terminal"
+)
+
+// completionFunc completes a flag value
+type completionFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// flagCompletions are the dynamic completions per flag name
+// Accounts and roles come from the cached catalog, completion never lists or logs in.
+var flagCompletions = map[string]completionFunc{
+	"account-id": completeAccountIDs,
+	"account":    completeAccounts,
+	"role-name":  completeRoles,
+	"role":       completeRoles,
+	"profile":    completeProfiles,
+	"region":     completeRegions,
+}
+
+// registerCompletions registers the flag completions on the command and its subcommands that have the flags
+func registerCompletions(cmd *cobra.Command) {
+	for name, complete := range flagCompletions {
+		if cmd.Flags().Lookup(name) != nil {
+			_ = cmd.RegisterFlagCompletionFunc(name, complete)
+		}
+	}
+	for _, child := range cmd.Commands() {
+		registerCompletions(child)
+	}
+}
+
+// completionConfig returns the config, or an empty config when there is none
+func completionConfig() *file.AppConfig {
+	conf, err := file.LoadConfig(file.GetConfigFilePath(ctx))
+	if err != nil {
+		return &file.AppConfig{}
+	}
+	return conf
+}
+
+// completionMetadata returns the account metadata of the config and account catalog,
+// or only the accounts of the config when the account catalog cannot be read
+func completionMetadata(conf *file.AppConfig) map[string]file.AccountMetadata {
+	metadata, err := conf.LoadAccountMetadata()
+	if err != nil {
+		return conf.Accounts
+	}
+	return metadata
+}
+
+// completionStartURL returns the start url set with --start-url, otherwise the one from config
+func completionStartURL(cmd *cobra.Command, conf *file.AppConfig) string {
+	if flag := cmd.Flags().Lookup("start-url"); flag != nil && flag.Changed {
+		return flag.Value.String()
+	}
+	return conf.StartURL
+}
+
+// completeAccountIDs completes cached account ids described by their names
+func completeAccountIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	conf := completionConfig()
+	var completions []string
+	for _, account := range amazon.CachedAccounts(ctx, completionStartURL(cmd, conf)) {
+		id := aws.ToString(account.AccountId)
+		if strings.HasPrefix(id, toComplete) {
+			completions = append(completions, fmt.Sprintf("%s\t%s", id, aws.ToString(account.AccountName)))
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeAccounts completes cached account names and the aliases from config and the account catalog described by their ids
+func completeAccounts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	conf := completionConfig()
+	metadata := completionMetadata(conf)
+	var completions []string
+	for _, account := range amazon.CachedAccounts(ctx, completionStartURL(cmd, conf)) {
+		id, name := aws.ToString(account.AccountId), aws.ToString(account.AccountName)
+		for _, value := range append([]string{name}, metadata[id].Aliases...) {
+			if strings.HasPrefix(strings.ToLower(value), strings.ToLower(toComplete)) {
+				completions = append(completions, fmt.Sprintf("%s\t%s %s", value, name, id))
+			}
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRoles completes the cached roles of the account set with --account-id or --account,
+// otherwise the cached roles of every account
+func completeRoles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	conf := completionConfig()
+	startURL := completionStartURL(cmd, conf)
+	accountID := completionAccountID(cmd, conf, startURL)
+
+	var completions []string
+	for _, role := range amazon.CachedRoles(ctx, startURL, accountID) {
+		if strings.HasPrefix(strings.ToLower(role), strings.ToLower(toComplete)) {
+			completions = append(completions, role)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completionAccountID returns the account id set with --account-id,
+// or of the cached account whose name, id or alias is set with --account
+func completionAccountID(cmd *cobra.Command, conf *file.AppConfig, startURL string) string {
+	if flag := cmd.Flags().Lookup("account-id"); flag != nil && flag.Changed {
+		return flag.Value.String()
+	}
+	flag := cmd.Flags().Lookup("account")
+	if flag == nil || !flag.Changed {
+		return ""
+	}
+	metadata := completionMetadata(conf)
+	for _, account := range amazon.CachedAccounts(ctx, startURL) {
+		id := aws.ToString(account.AccountId)
+		for _, value := range append([]string{id, aws.ToString(account.AccountName)}, metadata[id].Aliases...) {
+			if strings.EqualFold(value, flag.Value.String()) {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// completeProfiles completes the profiles of the aws credentials and config files
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return filterPrefix(amazon.ProfileNames(), toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRegions completes the selectable regions
+func completeRegions(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	regions := terminal.Regions()
+	sort.Strings(regions)
+	return filterPrefix(regions, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// filterPrefix returns the values starting with the prefix
+func filterPrefix(values []string, prefix string) []string {
+	var filtered []string
+	for _, value := range values {
+		if strings.HasPrefix(value, prefix) {
+			filtered = append(filtered, value)
+		}
+	}
+	return filtered
+}
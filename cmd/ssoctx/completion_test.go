@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"ssoctx/internal/file"
+)
+
+func TestRegisterCompletions(t *testing.T) {
+	registerCompletions(rootCmd)
+
+	for _, cmd := range []*cobra.Command{selectCmd, refreshCmd, assumeCmd} {
+		for _, name := range []string{"account-id", "role-name", "profile"} {
+			if _, ok := cmd.GetFlagCompletionFunc(name); !ok {
+				t.Errorf("%s --%s has no completion", cmd.Name(), name)
+			}
+		}
+	}
+	if _, ok := versionCmd.GetFlagCompletionFunc("profile"); ok {
+		t.Errorf("version --profile has a completion, but no such flag")
+	}
+}
+
+func TestCompleteRegions(t *testing.T) {
+	got, directive := completeRegions(selectCmd, nil, "us-west")
+	if want := []string{"us-west-1", "us-west-2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("completeRegions() = %v, want %v", got, want)
+	}
+	if directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("completeRegions() directive = %v, want no file completion", directive)
+	}
+}
+
+func TestCompletionMetadata(t *testing.T) {
+	catalog := filepath.Join(t.TempDir(), "accounts.yml")
+	if err := os.WriteFile(catalog, []byte("accounts:\n  \"111111111111\":\n    aliases: [payments]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	accounts := map[string]file.AccountMetadata{"222222222222": {Aliases: []string{"dev"}}}
+
+	conf := &file.AppConfig{AccountCatalog: catalog, Accounts: accounts}
+	if got := completionMetadata(conf)["111111111111"].Aliases; !reflect.DeepEqual(got, []string{"payments"}) {
+		t.Errorf("completionMetadata() aliases = %v, want the account catalog aliases", got)
+	}
+
+	// an unreadable account catalog completes the config accounts
+	conf = &file.AppConfig{AccountCatalog: filepath.Join(t.TempDir(), "missing.yml"), Accounts: accounts}
+	if got := completionMetadata(conf); !reflect.DeepEqual(got, accounts) {
+		t.Errorf("completionMetadata() = %v, want the config accounts", got)
+	}
+}
@@ -29,7 +29,7 @@ var (
 			if err != nil {
 				logger.Fatal().Err(err)
 			}
-			region = terminal.SelectRegion(terminal.NewSelectForm)
+			region = terminal.SelectRegion(terminal.Selector[string](picker))
 			if err := file.GenerateConfig(ctx, startURL, region); err != nil {
 				logger.Fatal().Err(err)
 			}
@@ -50,7 +50,7 @@ var (
 			if err != nil {
 				logger.Fatal().Err(err)
 			}
-			region = terminal.SelectRegion(terminal.NewSelectForm)
+			region = terminal.SelectRegion(terminal.Selector[string](pickerCommand(file.ReadConfig(ctx, file.GetConfigFilePath(ctx)))))
 			if err := file.EditConfig(ctx, startURL, region); err != nil {
 				logger.Fatal().Err(err)
 			}
@@ -62,6 +62,8 @@ func init() {
 	rootCmd.AddCommand(configCmd)
 	configCmd.AddCommand(generateCmd)
 	configCmd.AddCommand(editCmd)
+	generateCmd.Flags().StringVarP(&picker, "picker", "", "", "set the external picker command, like fzf")
+	editCmd.Flags().StringVarP(&picker, "picker", "", "", "set / override the external picker command, like fzf")
 	configCmd.Flags().BoolVarP(&debug, "debug", "", false, "toggle if you want to enable debug logs")
 	configCmd.Flags().BoolVarP(&jsonFormat, "json", "", false, "toggle if you want to enable json log output")
 }
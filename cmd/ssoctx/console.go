@@ -0,0 +1,48 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"ssoctx/internal/amazon"
+	"ssoctx/internal/file"
+)
+
+var consoleCmd = &cobra.Command{
+	Use:   "console",
+	Short: "Open the AWS console for an account and SSO role",
+	Long: `Open the AWS console for an account and SSO role.
+  Role credentials are exchanged for a federated sign-in url.
+  Use --destination to land on a service page and --print to only output the url.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := configureLogger(debug, jsonFormat)
+		ctx = logger.WithContext(ctx)
+
+		conf := file.GetConfigs(ctx, &startURL, &region)
+		oidc, sso := newClients(logger, conf)
+
+		amazon.Console(ctx, oidc, sso, amazon.ConsoleFlagInputs{
+			AccountID:       accountID,
+			RoleName:        roleName,
+			StartURL:        startURL,
+			Region:          region,
+			Destination:     destination,
+			Browser:         conf.Browser,
+			BrowserProfile:  browserProfile,
+			BrowserProfiles: conf.BrowserProfiles,
+			Print:           printURL,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(consoleCmd)
+	consoleCmd.Flags().StringVarP(&roleName, "role-name", "n", "", "set with permission set role name")
+	consoleCmd.Flags().StringVarP(&accountID, "account-id", "a", "", "set account id for desired aws account")
+	consoleCmd.Flags().StringVarP(&startURL, "start-url", "u", "", "set / override aws sso url start url")
+	consoleCmd.Flags().StringVarP(&region, "region", "r", "", "set / override aws region")
+	consoleCmd.Flags().StringVarP(&destination, "destination", "d", "", "console path or url to open, e.g. ec2/home")
+	consoleCmd.Flags().StringVarP(&browserProfile, "browser-profile", "", "", "set / override the browser profile used to open the console")
+	consoleCmd.Flags().BoolVarP(&printURL, "print", "", false, "outputs the sign-in url to stdout instead of opening the browser")
+	consoleCmd.Flags().BoolVarP(&debug, "debug", "", false, "toggle if you want to enable debug logs")
+	consoleCmd.Flags().BoolVarP(&jsonFormat, "json", "", false, "toggle if you want to enable json log output")
+}
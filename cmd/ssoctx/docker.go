@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"ssoctx/internal/amazon"
+	"ssoctx/internal/file"
+)
+
+// dockerCredentialHelperName is the binary name docker uses for the ssoctx credential helper
+const dockerCredentialHelperName = "docker-credential-ssoctx"
+
+var dockerCredentialCmd = &cobra.Command{
+	Use:       "docker-credential <get|store|erase|list>",
+	Short:     "Docker credential helper for ECR registries",
+	ValidArgs: []string{"get", "store", "erase", "list"},
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	Long: `Docker credential helper for ECR registries.
+  Registries are mapped to account ids and authorized with the configured role per account.
+  Link the binary as ` + dockerCredentialHelperName + ` and set "credHelpers" in the docker config.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// stdout is used by the credential helper protocol
+		logOutput = os.Stderr
+		logger := configureLogger(debug, jsonFormat)
+		ctx = logger.WithContext(ctx)
+
+		conf := file.GetConfigs(ctx, &startURL, &region)
+		oidc, sso := newClients(logger, conf)
+
+		err := amazon.DockerCredentialHelper(ctx, oidc, sso, amazon.DockerCredentialFlagInputs{
+			Action:      args[0],
+			StartURL:    startURL,
+			Region:      region,
+			DefaultRole: conf.ECR.DefaultRole,
+			Roles:       conf.ECR.Roles,
+		}, os.Stdin, os.Stdout)
+		if err != nil {
+			// docker reads the error message from stdout
+			fmt.Fprintln(os.Stdout, err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dockerCredentialCmd)
+	dockerCredentialCmd.Flags().BoolVarP(&debug, "debug", "", false, "toggle if you want to enable debug logs")
+	dockerCredentialCmd.Flags().BoolVarP(&jsonFormat, "json", "", false, "toggle if you want to enable json log output")
+}
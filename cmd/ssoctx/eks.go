@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"ssoctx/internal/amazon"
+	"ssoctx/internal/file"
+)
+
+var (
+	eksCmd = &cobra.Command{
+		Use:   "eks",
+		Short: "Handles EKS authentication",
+		Long:  "Handles EKS authentication with SSO role credentials.",
+	}
+
+	eksTokenCmd = &cobra.Command{
+		Use:   "token",
+		Short: "Output an EKS ExecCredential token",
+		Long: `Output a client.authentication.k8s.io ExecCredential for an EKS cluster.
+  The token is presigned locally with cached SSO role credentials.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			// stdout is parsed by kubectl as the ExecCredential
+			logOutput = os.Stderr
+			logger := configureLogger(debug, jsonFormat)
+			ctx = logger.WithContext(ctx)
+
+			conf := file.GetConfigs(ctx, &startURL, &region)
+			oidc, sso := newClients(logger, conf)
+
+			amazon.EKSToken(ctx, oidc, sso, amazon.EKSFlagInputs{
+				AccountID: accountID,
+				RoleName:  roleName,
+				StartURL:  startURL,
+				Region:    region,
+				Cluster:   cluster,
+			})
+		},
+	}
+
+	eksKubeconfigCmd = &cobra.Command{
+		Use:   "kubeconfig",
+		Short: "Write a kubeconfig user that uses ssoctx for EKS tokens",
+		Long: `Write a kubeconfig user entry whose exec plugin is ssoctx eks token.
+  Use --context to point an existing context at the user.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			logger := configureLogger(debug, jsonFormat)
+			ctx = logger.WithContext(ctx)
+
+			conf := file.GetConfigs(ctx, &startURL, &region)
+			oidc, sso := newClients(logger, conf)
+
+			amazon.EKSKubeconfig(ctx, oidc, sso, amazon.EKSFlagInputs{
+				AccountID:  accountID,
+				RoleName:   roleName,
+				StartURL:   startURL,
+				Region:     region,
+				Cluster:    cluster,
+				Kubeconfig: kubeconfig,
+				Context:    kubeContext,
+				UserName:   kubeUser,
+			})
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(eksCmd)
+	eksCmd.AddCommand(eksTokenCmd)
+	eksCmd.AddCommand(eksKubeconfigCmd)
+	for _, c := range []*cobra.Command{eksTokenCmd, eksKubeconfigCmd} {
+		c.Flags().StringVarP(&cluster, "cluster", "c", "", "set the eks cluster name")
+		c.Flags().StringVarP(&roleName, "role-name", "n", "", "set with permission set role name")
+		c.Flags().StringVarP(&accountID, "account-id", "a", "", "set account id for desired aws account")
+		c.Flags().StringVarP(&startURL, "start-url", "u", "", "set / override aws sso url start url")
+		c.Flags().StringVarP(&region, "region", "r", "", "set / override aws region")
+		c.Flags().BoolVarP(&debug, "debug", "", false, "toggle if you want to enable debug logs")
+		c.Flags().BoolVarP(&jsonFormat, "json", "", false, "toggle if you want to enable json log output")
+		_ = c.MarkFlagRequired("cluster")
+	}
+	_ = eksTokenCmd.MarkFlagRequired("account-id")
+	_ = eksTokenCmd.MarkFlagRequired("role-name")
+	eksKubeconfigCmd.Flags().StringVarP(&kubeconfig, "kubeconfig", "", "", "kubeconfig path to write, defaults to KUBECONFIG or ~/.kube/config")
+	eksKubeconfigCmd.Flags().StringVarP(&kubeContext, "context", "", "", "existing kubeconfig context to use the user")
+	eksKubeconfigCmd.Flags().StringVarP(&kubeUser, "user-name", "", "", "set / override the kubeconfig user name")
+}
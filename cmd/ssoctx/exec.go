@@ -0,0 +1,48 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"ssoctx/internal/amazon"
+	"ssoctx/internal/file"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec [flags] -- command [args...]",
+	Short: "Run a command with role credentials, SSM parameters and secrets",
+	Long: `Run a command with role credentials in its environment.
+  Parameters under each --ssm-path and each --secret are exported to the command only.
+  Env var names are derived from the parameter or secret name, use --env-map to set them.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := configureLogger(debug, jsonFormat)
+		ctx = logger.WithContext(ctx)
+
+		conf := file.GetConfigs(ctx, &startURL, &region)
+		oidc, sso := newClients(logger, conf)
+
+		amazon.Exec(ctx, oidc, sso, amazon.ExecFlagInputs{
+			AccountID: accountID,
+			RoleName:  roleName,
+			StartURL:  startURL,
+			Region:    region,
+			SSMPaths:  ssmPaths,
+			Secrets:   secrets,
+			EnvMap:    envMap,
+			Command:   args,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+	execCmd.Flags().StringSliceVarP(&ssmPaths, "ssm-path", "", nil, "export the ssm parameters under the path, can be repeated")
+	execCmd.Flags().StringSliceVarP(&secrets, "secret", "", nil, "export the secrets manager secret, can be repeated")
+	execCmd.Flags().StringToStringVarP(&envMap, "env-map", "", nil, "set the env var name of a parameter or secret as NAME=ENV")
+	execCmd.Flags().StringVarP(&roleName, "role-name", "n", "", "set with permission set role name")
+	execCmd.Flags().StringVarP(&accountID, "account-id", "a", "", "set account id for desired aws account")
+	execCmd.Flags().StringVarP(&startURL, "start-url", "u", "", "set / override aws sso url start url")
+	execCmd.Flags().StringVarP(&region, "region", "r", "", "set / override aws region")
+	execCmd.Flags().BoolVarP(&debug, "debug", "", false, "toggle if you want to enable debug logs")
+	execCmd.Flags().BoolVarP(&jsonFormat, "json", "", false, "toggle if you want to enable json log output")
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"ssoctx/internal/amazon"
+	"ssoctx/internal/file"
+)
+
+var foreachCmd = &cobra.Command{
+	Use:   "foreach [flags] -- command [args...]",
+	Short: "Run a command in many accounts in parallel",
+	Long: `Run a command once per account with the role credentials in its environment.
+  Accounts are matched by id or name with --accounts, or use --all.
+  Output lines are prefixed with the account and a summary of exit codes is printed at the end.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := configureLogger(debug, jsonFormat)
+		ctx = logger.WithContext(ctx)
+
+		conf := file.GetConfigs(ctx, &startURL, &region)
+		oidc, sso := newClients(logger, conf)
+
+		ok := amazon.Foreach(ctx, oidc, sso, amazon.ForeachFlagInputs{
+			RoleName: roleName,
+			StartURL: startURL,
+			Region:   region,
+			Accounts: accountsGlob,
+			All:      allAccounts,
+			Parallel: parallel,
+			Confirm:  confirm,
+			Command:  args,
+		})
+		if !ok {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(foreachCmd)
+	foreachCmd.Flags().StringVarP(&roleName, "role-name", "n", "", "set the permission set role name used in every account")
+	foreachCmd.Flags().StringVarP(&accountsGlob, "accounts", "", "", "run in accounts whose id or name matches the glob pattern")
+	foreachCmd.Flags().BoolVarP(&allAccounts, "all", "", false, "run in every account")
+	foreachCmd.Flags().IntVarP(&parallel, "parallel", "", 8, "set the number of accounts run at once")
+	foreachCmd.Flags().StringVarP(&confirm, "confirm", "", "", "confirm a safeguarded account by its name, id or alias without prompting")
+	foreachCmd.Flags().StringVarP(&startURL, "start-url", "u", "", "set / override aws sso url start url")
+	foreachCmd.Flags().StringVarP(&region, "region", "r", "", "set / override aws region")
+	foreachCmd.Flags().BoolVarP(&debug, "debug", "", false, "toggle if you want to enable debug logs")
+	foreachCmd.Flags().BoolVarP(&jsonFormat, "json", "", false, "toggle if you want to enable json log output")
+	foreachCmd.MarkFlagsOneRequired("accounts", "all")
+	foreachCmd.MarkFlagsMutuallyExclusive("accounts", "all")
+	_ = foreachCmd.MarkFlagRequired("role-name")
+}
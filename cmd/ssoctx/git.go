@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"ssoctx/internal/amazon"
+	"ssoctx/internal/file"
+)
+
+var gitCredentialCmd = &cobra.Command{
+	Use:       "git-credential <get|store|erase>",
+	Short:     "Git credential helper for CodeCommit repositories",
+	ValidArgs: []string{"get", "store", "erase"},
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	Long: `Git credential helper for CodeCommit https repositories.
+  Repositories are mapped to an account and role with the codecommit config.
+  The password is derived from the role credentials on every request and never stored.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// stdout is used by the credential helper protocol
+		logOutput = os.Stderr
+		logger := configureLogger(debug, jsonFormat)
+		ctx = logger.WithContext(ctx)
+
+		conf := file.GetConfigs(ctx, &startURL, &region)
+		oidc, sso := newClients(logger, conf)
+
+		err := amazon.GitCredentialHelper(ctx, oidc, sso, amazon.GitCredentialFlagInputs{
+			Action:       args[0],
+			StartURL:     startURL,
+			Repositories: conf.CodeCommit,
+		}, os.Stdin, os.Stdout)
+		if err != nil {
+			logger.Fatal().Msgf("Encountered error in git credential helper: %v", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(gitCredentialCmd)
+	gitCredentialCmd.Flags().BoolVarP(&debug, "debug", "", false, "toggle if you want to enable debug logs")
+	gitCredentialCmd.Flags().BoolVarP(&jsonFormat, "json", "", false, "toggle if you want to enable json log output")
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"ssoctx/internal/amazon"
+	"ssoctx/internal/file"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the accounts and roles you can access",
+	Long: `List the accounts and roles you can access without prompting.
+  Use --roles to list the roles of every account and --output for table, json, csv or yaml.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// stdout is kept for the list so it can be piped
+		logOutput = os.Stderr
+		logger := configureLogger(debug, jsonFormat)
+		ctx = logger.WithContext(ctx)
+
+		conf := file.GetConfigs(ctx, &startURL, &region)
+		oidc, sso := newClients(logger, conf)
+
+		amazon.List(ctx, oidc, sso, amazon.ListFlagInputs{
+			StartURL:   startURL,
+			Accounts:   accountsGlob,
+			Roles:      listRoles,
+			RoleFilter: roleFilter,
+			Output:     output,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+	listCmd.Flags().BoolVarP(&listRoles, "roles", "", false, "list the roles of every account")
+	listCmd.Flags().StringVarP(&accountsGlob, "accounts", "", "", "list accounts whose id or name matches the glob pattern")
+	listCmd.Flags().StringVarP(&roleFilter, "role-filter", "", "", "list roles whose name matches the glob pattern, implies --roles")
+	listCmd.Flags().StringVarP(&output, "output", "o", "table", "set the output format: table, json, csv or yaml")
+	listCmd.Flags().StringVarP(&startURL, "start-url", "u", "", "set / override aws sso url start url")
+	listCmd.Flags().StringVarP(&region, "region", "r", "", "set / override aws region")
+	listCmd.Flags().BoolVarP(&debug, "debug", "", false, "toggle if you want to enable debug logs")
+	listCmd.Flags().BoolVarP(&jsonFormat, "json", "", false, "toggle if you want to enable json log output")
+	_ = listCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions([]string{"table", "json", "csv", "yaml"}, cobra.ShellCompDirectiveNoFileComp))
+}
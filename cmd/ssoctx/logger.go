@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
@@ -10,6 +11,10 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// logOutput is where human readable logs are written
+// commands that use stdout for a protocol write logs to stderr instead
+var logOutput io.Writer = os.Stdout
+
 // configureLogger sets globals and returns a logger
 func configureLogger(debug, jsonFormat bool) zerolog.Logger {
 	// set default time
@@ -24,7 +29,7 @@ func configureLogger(debug, jsonFormat bool) zerolog.Logger {
 	// default to human readable log output
 	if !jsonFormat {
 		output := zerolog.ConsoleWriter{
-			Out:        os.Stdout,
+			Out:        logOutput,
 			TimeFormat: time.RFC3339,
 		}
 
@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -20,6 +22,42 @@ var (
 	jsonFormat bool   // used to enable json logging
 	printCreds bool   // used to print creds
 
+	destination    string            // used to store the console destination
+	browserProfile string            // used to store the browser profile
+	printURL       bool              // used to print urls instead of opening the browser
+	chainName      string            // used to store the role chain name
+	cluster        string            // used to store the eks cluster name
+	kubeconfig     string            // used to store the kubeconfig path
+	kubeContext    string            // used to store the kubeconfig context name
+	kubeUser       string            // used to store the kubeconfig user name
+	dbHost         string            // used to store the database host
+	dbPort         int               // used to store the database port
+	dbUser         string            // used to store the database user
+	dbExport       string            // used to store the database client to export the token for
+	pgpass         bool              // used to write the token to the .pgpass file
+	service        string            // used to store the signing name of the proxied service
+	upstream       string            // used to store the proxy upstream url
+	listen         string            // used to store the proxy listen address
+	ssmPaths       []string          // used to store the ssm parameter paths to export
+	secrets        []string          // used to store the secrets to export
+	envMap         map[string]string // used to store env var names per parameter or secret
+	accountsGlob   string            // used to store the accounts pattern
+	allAccounts    bool              // used to select every account
+	parallel       int               // used to store the number of accounts run at once
+	listRoles      bool              // used to list the roles of every account
+	roleFilter     string            // used to store the roles pattern
+	output         string            // used to store the output format
+	refreshCatalog bool              // used to reload the cached account and role catalog
+	picker         string            // used to store the external picker command
+	accountPattern string            // used to store the account name, alias, id or pattern
+	rolePattern    string            // used to store the role name pattern
+	confirm        string            // used to store the account typed ahead for safeguarded accounts
+	pickRole       bool              // used to prompt for the role instead of using the role preference
+	multi          bool              // used to select several accounts and roles
+	profileTmpl    string            // used to store the template naming profiles of several accounts and roles
+	native         bool              // used to write native sso profiles to the aws config file
+	dryRun         bool              // used to only show the changes
+
 	ctx     = context.Background()
 	version = "v0.0.0+unknown"
 	commit  = "unknown"
@@ -33,6 +71,11 @@ Use to login to SSO portal and refresh session.`,
 )
 
 func main() {
+	// docker runs credential helpers as docker-credential-<name> <action>
+	if strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe") == dockerCredentialHelperName {
+		rootCmd.SetArgs(append([]string{dockerCredentialCmd.Name()}, os.Args[1:]...))
+	}
+	registerCompletions(rootCmd)
 	err := rootCmd.Execute()
 	if err != nil {
 		os.Exit(1)
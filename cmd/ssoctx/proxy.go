@@ -0,0 +1,48 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"ssoctx/internal/amazon"
+	"ssoctx/internal/file"
+)
+
+var proxyCmd = &cobra.Command{
+	Use:   "proxy",
+	Short: "Run a local proxy that SigV4 signs requests",
+	Long: `Run a local reverse proxy that SigV4 signs every request to an IAM authenticated upstream.
+  Use it to reach OpenSearch dashboards, API Gateway IAM endpoints or Lambda function URLs from a browser.
+  Role credentials are refreshed as they expire.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := configureLogger(debug, jsonFormat)
+		ctx = logger.WithContext(ctx)
+
+		conf := file.GetConfigs(ctx, &startURL, &region)
+		oidc, sso := newClients(logger, conf)
+
+		amazon.Proxy(ctx, oidc, sso, amazon.ProxyFlagInputs{
+			AccountID: accountID,
+			RoleName:  roleName,
+			StartURL:  startURL,
+			Region:    region,
+			Service:   service,
+			Upstream:  upstream,
+			Listen:    listen,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(proxyCmd)
+	proxyCmd.Flags().StringVarP(&service, "service", "s", "", "set the signing name of the service, like es, execute-api or lambda")
+	proxyCmd.Flags().StringVarP(&upstream, "upstream", "", "", "set the upstream url to proxy to")
+	proxyCmd.Flags().StringVarP(&listen, "listen", "l", "127.0.0.1:8080", "set the local address to listen on")
+	proxyCmd.Flags().StringVarP(&roleName, "role-name", "n", "", "set with permission set role name")
+	proxyCmd.Flags().StringVarP(&accountID, "account-id", "a", "", "set account id for desired aws account")
+	proxyCmd.Flags().StringVarP(&startURL, "start-url", "u", "", "set / override aws sso url start url")
+	proxyCmd.Flags().StringVarP(&region, "region", "r", "", "set / override aws region")
+	proxyCmd.Flags().BoolVarP(&debug, "debug", "", false, "toggle if you want to enable debug logs")
+	proxyCmd.Flags().BoolVarP(&jsonFormat, "json", "", false, "toggle if you want to enable json log output")
+	_ = proxyCmd.MarkFlagRequired("service")
+	_ = proxyCmd.MarkFlagRequired("upstream")
+}
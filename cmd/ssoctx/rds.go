@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"ssoctx/internal/amazon"
+	"ssoctx/internal/file"
+)
+
+var (
+	rdsCmd = &cobra.Command{
+		Use:   "rds",
+		Short: "Handles RDS IAM database authentication",
+		Long:  "Handles RDS IAM database authentication with SSO role credentials.",
+	}
+
+	rdsTokenCmd = &cobra.Command{
+		Use:   "token",
+		Short: "Output an RDS IAM database auth token",
+		Long: `Output an RDS IAM database auth token for the host, port and user.
+  The token is presigned locally with the SSO role credentials.
+  Use --export pg or --export mysql to output an export of the password variable,
+  or --pgpass to write the token to the .pgpass file.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			// stdout is captured as the password, e.g. PGPASSWORD=$(ssoctx rds token ...)
+			logOutput = os.Stderr
+			logger := configureLogger(debug, jsonFormat)
+			ctx = logger.WithContext(ctx)
+
+			conf := file.GetConfigs(ctx, &startURL, &region)
+			oidc, sso := newClients(logger, conf)
+
+			amazon.RDSToken(ctx, oidc, sso, amazon.RDSFlagInputs{
+				AccountID: accountID,
+				RoleName:  roleName,
+				StartURL:  startURL,
+				Region:    region,
+				Host:      dbHost,
+				Port:      dbPort,
+				User:      dbUser,
+				Export:    dbExport,
+				PGPass:    pgpass,
+			})
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(rdsCmd)
+	rdsCmd.AddCommand(rdsTokenCmd)
+	rdsTokenCmd.Flags().StringVarP(&dbHost, "host", "", "", "set the database endpoint host")
+	rdsTokenCmd.Flags().IntVarP(&dbPort, "port", "", 5432, "set the database port")
+	rdsTokenCmd.Flags().StringVarP(&dbUser, "user", "", "", "set the database user")
+	rdsTokenCmd.Flags().StringVarP(&dbExport, "export", "", "", "output an export of the password variable for pg or mysql")
+	rdsTokenCmd.Flags().BoolVarP(&pgpass, "pgpass", "", false, "write the token to the .pgpass file")
+	rdsTokenCmd.Flags().StringVarP(&roleName, "role-name", "n", "", "set with permission set role name")
+	rdsTokenCmd.Flags().StringVarP(&accountID, "account-id", "a", "", "set account id for desired aws account")
+	rdsTokenCmd.Flags().StringVarP(&startURL, "start-url", "u", "", "set / override aws sso url start url")
+	rdsTokenCmd.Flags().StringVarP(&region, "region", "r", "", "set / override aws region")
+	rdsTokenCmd.Flags().BoolVarP(&debug, "debug", "", false, "toggle if you want to enable debug logs")
+	rdsTokenCmd.Flags().BoolVarP(&jsonFormat, "json", "", false, "toggle if you want to enable json log output")
+	rdsTokenCmd.MarkFlagsMutuallyExclusive("export", "pgpass")
+	_ = rdsTokenCmd.MarkFlagRequired("host")
+	_ = rdsTokenCmd.MarkFlagRequired("user")
+}
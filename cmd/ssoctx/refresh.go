@@ -1,8 +1,6 @@
 package main
 
 import (
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/spf13/cobra"
 
 	"ssoctx/internal/amazon"
@@ -21,24 +19,18 @@ var refreshCmd = &cobra.Command{
 		conf := file.ReadConfig(ctx, file.GetConfigFilePath(ctx))
 		startURL = conf.StartURL
 		region = conf.Region
-		cfg, err := config.LoadDefaultConfig(ctx,
-			config.WithRegion(region),
-			config.WithCredentialsProvider(aws.AnonymousCredentials{}),
-		)
-		if err != nil {
-			logger.Fatal().Msgf("Encountered error in loading default aws config: %v", err)
-		}
-		oidcClient, ssoClient := amazon.NewClients(cfg)
-		oidc := amazon.NewOIDCClient(oidcClient, startURL)
-		sso := amazon.NewSSOClient(ssoClient)
+		oidc, sso := newClients(logger, conf)
 
 		amazon.Credentials(ctx, oidc, sso, amazon.RefreshFlagInputs{
 			AccountID: accountID,
 			RoleName:  roleName,
+			Account:   accountPattern,
+			Role:      rolePattern,
 			Profile:   profile,
 			StartURL:  startURL,
 			Region:    region,
 			Keys:      keys,
+			Confirm:   confirm,
 		})
 	},
 }
@@ -47,8 +39,16 @@ func init() {
 	rootCmd.AddCommand(refreshCmd)
 	refreshCmd.Flags().StringVarP(&roleName, "role-name", "n", "", "set with permission set role name")
 	refreshCmd.Flags().StringVarP(&accountID, "account-id", "a", "", "set account id for desired aws account")
+	refreshCmd.Flags().StringVarP(&accountPattern, "account", "", "", "set account by name, alias, id, glob or /regex/")
+	refreshCmd.Flags().StringVarP(&rolePattern, "role", "", "", "set role by name, glob or /regex/")
 	refreshCmd.Flags().StringVarP(&profile, "profile", "p", "default", "the profile name to set in credentials file")
 	refreshCmd.Flags().BoolVarP(&keys, "keys", "", false, "toggle if you want to write access/secret keys to credentials file")
+	refreshCmd.Flags().BoolVarP(&pickRole, "pick-role", "", false, "toggle if you want to choose the role instead of using the role preference")
+	refreshCmd.Flags().StringVarP(&confirm, "confirm", "", "", "confirm a safeguarded account by its name, id or alias without prompting")
+	refreshCmd.Flags().StringVarP(&picker, "picker", "", "", "set / override the external picker command, like fzf")
+	refreshCmd.Flags().BoolVarP(&refreshCatalog, "refresh-catalog", "", false, "toggle if you want to reload the cached accounts and roles")
 	refreshCmd.Flags().BoolVarP(&debug, "debug", "", false, "toggle if you want to enable debug logs")
 	refreshCmd.Flags().BoolVarP(&jsonFormat, "json", "", false, "toggle if you want to enable json log output")
+	refreshCmd.MarkFlagsMutuallyExclusive("account", "account-id")
+	refreshCmd.MarkFlagsMutuallyExclusive("role", "role-name")
 }
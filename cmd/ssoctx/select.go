@@ -1,8 +1,6 @@
 package main
 
 import (
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/spf13/cobra"
 
 	"ssoctx/internal/amazon"
@@ -12,34 +10,43 @@ import (
 // selectCmd represents the login command
 var (
 	selectCmd = &cobra.Command{
-		Use:   "select",
-		Short: "Login to AWS SSO and select account and role",
-		Long:  "Login to AWS SSO by retrieving short-lived credentials for account and role.",
+		Use:       "select [-]",
+		Short:     "Login to AWS SSO and select account and role",
+		Long:      "Login to AWS SSO by retrieving short-lived credentials for account and role.\n  Use \"select -\" to switch back to the previously selected account and role.",
+		ValidArgs: []string{"-"},
+		Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
 		Run: func(cmd *cobra.Command, args []string) {
 			logger := configureLogger(debug, jsonFormat)
 			ctx = logger.WithContext(ctx)
 
-			file.GetConfigs(ctx, &startURL, &region)
-			cfg, err := config.LoadDefaultConfig(ctx,
-				config.WithRegion(region),
-				config.WithCredentialsProvider(aws.AnonymousCredentials{}),
-			)
-			if err != nil {
-				logger.Fatal().Msgf("Encountered error in loading default aws config: %v", err)
+			previous := len(args) == 1
+			if previous && (len(accountID) > 0 || len(roleName) > 0 || len(accountPattern) > 0 || len(rolePattern) > 0 || len(chainName) > 0) {
+				logger.Fatal().Msg("select - cannot be combined with account, role or chain flags")
 			}
-			oidcClient, ssoClient := amazon.NewClients(cfg)
-			oidc := amazon.NewOIDCClient(oidcClient, startURL)
-			sso := amazon.NewSSOClient(ssoClient)
+			if multi && (previous || len(accountID) > 0 || len(roleName) > 0 || len(accountPattern) > 0 || len(rolePattern) > 0 || len(chainName) > 0 || printCreds) {
+				logger.Fatal().Msg("--multi cannot be combined with select -, account, role, chain or print-creds flags")
+			}
+
+			conf := file.GetConfigs(ctx, &startURL, &region)
+			chain := roleChain(logger, conf)
+			oidc, sso := newClients(logger, conf)
 
 			amazon.Select(ctx, oidc, sso, amazon.SelectFlagInputs{
-				AccountID:  accountID,
-				RoleName:   roleName,
-				Profile:    profile,
-				StartURL:   startURL,
-				Region:     region,
-				Keys:       keys,
-				Clean:      clean,
-				PrintCreds: printCreds,
+				AccountID:       accountID,
+				RoleName:        roleName,
+				Account:         accountPattern,
+				Role:            rolePattern,
+				Profile:         profile,
+				StartURL:        startURL,
+				Region:          region,
+				Keys:            keys,
+				Clean:           clean,
+				PrintCreds:      printCreds,
+				Previous:        previous,
+				Chain:           chain,
+				Confirm:         confirm,
+				Multi:           multi,
+				ProfileTemplate: profileTmpl,
 			})
 		},
 	}
@@ -49,6 +56,8 @@ func init() {
 	rootCmd.AddCommand(selectCmd)
 	selectCmd.Flags().StringVarP(&roleName, "role-name", "n", "", "set with permission set role name")
 	selectCmd.Flags().StringVarP(&accountID, "account-id", "a", "", "set account id for desired aws account")
+	selectCmd.Flags().StringVarP(&accountPattern, "account", "", "", "set account by name, alias, id, glob or /regex/")
+	selectCmd.Flags().StringVarP(&rolePattern, "role", "", "", "set role by name, glob or /regex/")
 	selectCmd.Flags().StringVarP(&startURL, "start-url", "u", "", "set / override aws sso url start url")
 	selectCmd.Flags().StringVarP(&region, "region", "r", "", "set / override aws region")
 	selectCmd.Flags().StringVarP(&profile, "profile", "p", "default", "the profile name to set in credentials file")
@@ -56,5 +65,16 @@ func init() {
 	selectCmd.Flags().BoolVarP(&clean, "clean", "", false, "toggle if you want to remove lock and access token")
 	selectCmd.Flags().BoolVarP(&debug, "debug", "", false, "toggle if you want to enable debug logs")
 	selectCmd.Flags().BoolVarP(&jsonFormat, "json", "", false, "toggle if you want to enable json log output")
+	selectCmd.Flags().StringVarP(&chainName, "chain", "", "", "set a role chain from config to assume on top of the SSO role")
+	selectCmd.Flags().StringVarP(&picker, "picker", "", "", "set / override the external picker command, like fzf")
+	selectCmd.Flags().BoolVarP(&refreshCatalog, "refresh-catalog", "", false, "toggle if you want to reload the cached accounts and roles")
+	selectCmd.Flags().BoolVarP(&pickRole, "pick-role", "", false, "toggle if you want to choose the role instead of using the role preference")
+	selectCmd.Flags().StringVarP(&confirm, "confirm", "", "", "confirm a safeguarded account by its name, id or alias without prompting")
+	selectCmd.Flags().BoolVarP(&multi, "multi", "", false, "toggle if you want to select several accounts and roles, writing a profile each")
+	selectCmd.Flags().StringVarP(&profileTmpl, "profile-template", "", amazon.DefaultProfileTemplate, "the template naming the profiles written with --multi")
 	selectCmd.Flags().BoolVarP(&printCreds, "print-creds", "", false, "outputs the credentials to stdout and not modifying credentials file")
+	selectCmd.MarkFlagsMutuallyExclusive("account", "account-id")
+	selectCmd.MarkFlagsMutuallyExclusive("role", "role-name")
+	selectCmd.MarkFlagsMutuallyExclusive("account", "chain")
+	selectCmd.MarkFlagsMutuallyExclusive("role", "chain")
 }
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"ssoctx/internal/amazon"
+	"ssoctx/internal/file"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Write a profile for every account and role you can access",
+	Long: `Write a credential_process profile for every account and role you can access, named by --profile-template.
+  Use --native to write sso profiles to the aws config file instead. Synced profiles are marked as managed,
+  so profiles of access that was removed are pruned, while profiles you wrote yourself are never touched.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := configureLogger(debug, jsonFormat)
+		ctx = logger.WithContext(ctx)
+
+		conf := file.GetConfigs(ctx, &startURL, &region)
+		oidc, sso := newClients(logger, conf)
+
+		amazon.Sync(ctx, oidc, sso, amazon.SyncFlagInputs{
+			StartURL:        startURL,
+			Region:          region,
+			ProfileTemplate: profileTmpl,
+			Native:          native,
+			DryRun:          dryRun,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+	syncCmd.Flags().StringVarP(&profileTmpl, "profile-template", "", amazon.DefaultProfileTemplate, "the template naming the synced profiles")
+	syncCmd.Flags().BoolVarP(&native, "native", "", false, "toggle if you want to write native sso profiles to the aws config file")
+	syncCmd.Flags().BoolVarP(&dryRun, "dry-run", "", false, "toggle if you want to only show the profiles added, updated and removed")
+	syncCmd.Flags().StringVarP(&startURL, "start-url", "u", "", "set / override aws sso url start url")
+	syncCmd.Flags().StringVarP(&region, "region", "r", "", "set / override aws region")
+	syncCmd.Flags().BoolVarP(&debug, "debug", "", false, "toggle if you want to enable debug logs")
+	syncCmd.Flags().BoolVarP(&jsonFormat, "json", "", false, "toggle if you want to enable json log output")
+}
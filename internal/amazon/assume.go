@@ -6,7 +6,10 @@ import (
 	"os"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/service/sso"
 	"github.com/rs/zerolog"
+
+	"ssoctx/internal/file"
 )
 
 // AccessToken is used to marshal results from GetRoleCredentials
@@ -20,11 +23,12 @@ type AccessToken struct {
 
 // AssumeFlagInputs contains all needed inputs for Credentials
 type AssumeFlagInputs struct {
-	AccountID string // flag input from assume
-	RoleName  string // flag input from assume
-	StartURL  string // flag input from assume
-	Region    string // flag input from assume
-	Profile   string // flag input from assume
+	AccountID string          // flag input from assume
+	RoleName  string          // flag input from assume
+	StartURL  string          // flag input from assume
+	Region    string          // flag input from assume
+	Profile   string          // flag input from assume
+	Chain     *file.RoleChain // role chain from assume --chain
 }
 
 // AssumeCredentialProcess is used to return auth through credential process
@@ -32,31 +36,60 @@ type AssumeFlagInputs struct {
 // Sends expected json marshalled response to stdout for the aws credentials file credential process
 func AssumeCredentialProcess(ctx context.Context, o *OIDCClientAPI, s *Client, inputs AssumeFlagInputs) {
 	logger := zerolog.Ctx(ctx)
+	enforceStartURL(ctx, s, inputs.StartURL)
+
+	if inputs.Chain != nil {
+		inputs.AccountID = inputs.Chain.AccountID
+		inputs.RoleName = inputs.Chain.RoleName
+	}
+	// no token is fetched before the account id is checked, so no accounts are suggested
+	checkAccountID(ctx, s, "", inputs.AccountID)
+	enforcePolicy(ctx, s, "", inputs.AccountID, inputs.RoleName, inputs.Profile, file.OutputProcess)
+
+	ssoCredentials := func() (*sso.GetRoleCredentialsOutput, error) {
+		clientInfoDestination := clientInfoFileDestination(inputs.StartURL)
+		clientInformation, _ := o.processClientInformation(ctx, clientInfoDestination)
+		writeSecretToFile(ctx, &clientInformation, clientInfoFileDestination(inputs.StartURL))
+		if len(inputs.StartURL) == 0 {
+			inputs.StartURL = clientInformation.StartURL
+		}
+		roleCredentials, err := s.getRolesCredentials(
+			ctx,
+			inputs.AccountID,
+			inputs.RoleName,
+			clientInformation.AccessToken,
+		)
+		return roleCredentials, checkRoleCredentialsError(ctx, s, clientInformation.AccessToken, inputs.AccountID, inputs.RoleName, err)
+	}
 
-	clientInfoDestination := clientInfoFileDestination(inputs.StartURL)
-	clientInformation, _ := o.processClientInformation(ctx, clientInfoDestination)
-	writeStructToFile(ctx, &clientInformation, clientInfoFileDestination(inputs.StartURL))
-	roleCredentials, err := s.getRolesCredentials(
-		ctx,
-		inputs.AccountID,
-		inputs.RoleName,
-		clientInformation.AccessToken,
+	var (
+		roleCredentials *sso.GetRoleCredentialsOutput
+		template        CredentialsTemplate
+		err             error
 	)
+	expiration := time.Now().Add(1 * time.Hour)
+	if inputs.Chain != nil {
+		roleCredentials, err = resolveChainCredentials(ctx, inputs.Chain, inputs.StartURL, inputs.Region, s.safeguardedDuration(ctx, inputs.AccountID, inputs.RoleName), ssoCredentials)
+		if err == nil {
+			expiration = time.UnixMilli(roleCredentials.RoleCredentials.Expiration)
+		}
+		template = getChainCredentialProcess(inputs.Chain.Name, inputs.Region, inputs.StartURL)
+	} else {
+		roleCredentials, err = ssoCredentials()
+		template = getCredentialProcess(inputs.AccountID, inputs.RoleName, inputs.Region, inputs.StartURL)
+	}
 	if err != nil {
 		logger.Fatal().Msgf("Something went wrong: %q", err)
 	}
+	// capped credentials make aws clients run the credential process again sooner
+	expiration = capExpiration(expiration, s.safeguardedDuration(ctx, inputs.AccountID, inputs.RoleName))
 
-	if len(inputs.StartURL) == 0 {
-		inputs.StartURL = clientInformation.StartURL
-	}
-
-	template := getCredentialProcess(inputs.AccountID, inputs.RoleName, inputs.Region, inputs.StartURL)
 	writeAWSCredentialsFile(ctx, &template, inputs.Profile)
 
 	creds := AccessToken{
 		Version:         1,
 		AccessKeyID:     *roleCredentials.RoleCredentials.AccessKeyId,
-		Expiration:      time.Now().Add(1 * time.Hour).Format(time.RFC3339),
+		Expiration:      expiration.Format(time.RFC3339),
 		SecretAccessKey: *roleCredentials.RoleCredentials.SecretAccessKey,
 		SessionToken:    *roleCredentials.RoleCredentials.SessionToken,
 	}
@@ -0,0 +1,120 @@
+package amazon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/rs/zerolog"
+)
+
+var (
+	// credentialsExpiryWindow is how long before expiration cached credentials are refreshed
+	credentialsExpiryWindow = 5 * time.Minute
+
+	credentialsCacheDestination = actualCredentialsCacheDestination
+	invalidCacheKeyChars        = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+)
+
+// cachedCredentials is used to cache role credentials between runs
+type cachedCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      time.Time
+	CachedAt        time.Time
+}
+
+func actualCredentialsCacheDestination(key string) string {
+	homeDir, _ := os.UserHomeDir()
+	name := fmt.Sprintf("%s-%s.json", ProjectFileName, invalidCacheKeyChars.ReplaceAllString(key, "_"))
+	return filepath.Join(homeDir, ".aws", "sso", "cache", name)
+}
+
+// readCachedCredentials returns the cached credentials for key when they are not about to expire
+// The expiration is capped to maxDuration after caching, so credentials cached before a cap was set are not kept longer.
+func readCachedCredentials(ctx context.Context, key string, maxDuration time.Duration) (*sso.GetRoleCredentialsOutput, bool) {
+	logger := zerolog.Ctx(ctx)
+	destination := credentialsCacheDestination(key)
+	if !exists(ctx, destination) {
+		return nil, false
+	}
+
+	content, err := os.ReadFile(destination)
+	if err != nil {
+		logger.Debug().Msgf("Unable to read cached credentials %s: %v", destination, err)
+		return nil, false
+	}
+	cached := cachedCredentials{}
+	if err := json.Unmarshal(content, &cached); err != nil {
+		logger.Debug().Msgf("Unable to unmarshal cached credentials %s: %v", destination, err)
+		return nil, false
+	}
+	if maxDuration > 0 {
+		if capped := cached.CachedAt.Add(maxDuration); capped.Before(cached.Expiration) {
+			cached.Expiration = capped
+		}
+	}
+	if cached.Expiration.Before(time.Now().Add(credentialsExpiryWindow)) {
+		logger.Debug().Msgf("Cached credentials %s expired at %v", destination, cached.Expiration)
+		return nil, false
+	}
+
+	return &sso.GetRoleCredentialsOutput{
+		RoleCredentials: &types.RoleCredentials{
+			AccessKeyId:     aws.String(cached.AccessKeyID),
+			SecretAccessKey: aws.String(cached.SecretAccessKey),
+			SessionToken:    aws.String(cached.SessionToken),
+			Expiration:      cached.Expiration.UnixMilli(),
+		},
+	}, true
+}
+
+// writeCachedCredentials caches the credentials for key until they expire or maxDuration passed, when set
+func writeCachedCredentials(ctx context.Context, key string, creds *sso.GetRoleCredentialsOutput, maxDuration time.Duration) {
+	cached := cachedCredentials{
+		AccessKeyID:     *creds.RoleCredentials.AccessKeyId,
+		SecretAccessKey: *creds.RoleCredentials.SecretAccessKey,
+		SessionToken:    *creds.RoleCredentials.SessionToken,
+		Expiration:      capExpiration(time.UnixMilli(creds.RoleCredentials.Expiration), maxDuration),
+		CachedAt:        time.Now(),
+	}
+	writeSecretToFile(ctx, &cached, credentialsCacheDestination(key))
+}
+
+// cachedRoleCredentials returns cached role credentials for the account and role
+// When nothing valid is cached, the credentials are retrieved through sso and cached
+func cachedRoleCredentials(ctx context.Context, o *OIDCClientAPI, s *Client, accountID, roleName, startURL string) (*sso.GetRoleCredentialsOutput, error) {
+	key := roleCacheKey(startURL, accountID, roleName)
+	maxDuration := s.safeguardedDuration(ctx, accountID, roleName)
+	if cached, ok := readCachedCredentials(ctx, key, maxDuration); ok {
+		return cached, nil
+	}
+
+	destination := clientInfoFileDestination(startURL)
+	clientInformation, err := o.processClientInformation(ctx, destination)
+	if err != nil {
+		return &sso.GetRoleCredentialsOutput{}, err
+	}
+	writeSecretToFile(ctx, &clientInformation, destination)
+
+	roleCredentials, err := s.getRolesCredentials(ctx, accountID, roleName, clientInformation.AccessToken)
+	if err != nil {
+		return &sso.GetRoleCredentialsOutput{}, err
+	}
+	writeCachedCredentials(ctx, key, roleCredentials, maxDuration)
+	return roleCredentials, nil
+}
+
+// roleCacheKey returns the cache key for role credentials of an account and role of a start url
+// The start url is part of the key, so sso instances with the same account id and role name do not share credentials.
+func roleCacheKey(startURL, accountID, roleName string) string {
+	return fmt.Sprintf("role-%s-%s-%s", startURL, accountID, roleName)
+}
@@ -0,0 +1,190 @@
+package amazon
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/rs/zerolog"
+
+	"ssoctx/internal/terminal"
+)
+
+// defaultCatalogTTL is how long the cached catalog is used without refreshing
+const defaultCatalogTTL = time.Hour
+
+// catalogRefreshTimeout is how long listing accounts for the catalog is waited for after an account is picked
+var catalogRefreshTimeout = 10 * time.Second
+
+// CatalogOptions configures the cached account and role catalog of a start url
+type CatalogOptions struct {
+	StartURL string
+	TTL      time.Duration // defaults to defaultCatalogTTL
+	Refresh  bool          // ignore the cache and list everything again
+}
+
+// accountCatalog is the cached account and role listing of a start url
+type accountCatalog struct {
+	StartURL          string
+	AccountsUpdatedAt time.Time
+	Accounts          []types.AccountInfo
+	Roles             map[string]cachedRoles // roles per account id
+}
+
+// cachedRoles is the cached role listing of an account
+type cachedRoles struct {
+	UpdatedAt time.Time
+	Roles     []types.RoleInfo
+}
+
+// catalog is the state of the catalog used by a Client
+type catalog struct {
+	options CatalogOptions
+	live    bool       // listing errors are returned instead of falling back to the cache
+	mu      sync.Mutex // serializes read-modify-write of the catalog file
+}
+
+// UseCatalog enables the account and role catalog cache for the start url
+func (c *Client) UseCatalog(options CatalogOptions) {
+	if len(options.StartURL) == 0 {
+		return
+	}
+	if options.TTL <= 0 {
+		options.TTL = defaultCatalogTTL
+	}
+	c.catalog = &catalog{options: options}
+}
+
+// catalogAccounts returns the cached accounts and whether they can be used without listing again
+// Cached accounts are returned even when stale, so they can be shown while listing or used when listing fails
+func (c *Client) catalogAccounts(ctx context.Context) ([]types.AccountInfo, bool) {
+	if c.catalog == nil {
+		return nil, false
+	}
+	cat := c.readCatalog(ctx)
+	return cat.Accounts, !c.catalog.options.Refresh && time.Since(cat.AccountsUpdatedAt) < c.catalog.options.TTL
+}
+
+// listLive makes every listing reach the portal, listing errors are returned instead of cached data
+// The listings are still written to the catalog.
+func (c *Client) listLive() {
+	if c.catalog == nil {
+		return
+	}
+	c.catalog.options.Refresh = true
+	c.catalog.live = true
+}
+
+// cacheFallback returns true when cached data is used after a listing fails
+func (c *Client) cacheFallback() bool {
+	return c.catalog != nil && !c.catalog.live
+}
+
+// refreshingCatalog returns true when the catalog is forced to reload
+func (c *Client) refreshingCatalog() bool {
+	return c.catalog != nil && c.catalog.options.Refresh
+}
+
+// saveCatalogAccounts replaces the cached accounts
+func (c *Client) saveCatalogAccounts(ctx context.Context, accounts []types.AccountInfo) {
+	c.updateCatalog(ctx, func(cat *accountCatalog) {
+		cat.Accounts = accounts
+		cat.AccountsUpdatedAt = time.Now()
+	})
+}
+
+// catalogRoles returns the roles of the account from the catalog while within the ttl
+// Otherwise the roles are listed and cached, falling back to the cache when listing fails
+func (c *Client) catalogRoles(ctx context.Context, accountID, accessToken string) (*sso.ListAccountRolesOutput, error) {
+	if c.catalog == nil {
+		return c.listAvailableRoles(ctx, accountID, accessToken)
+	}
+	logger := zerolog.Ctx(ctx)
+
+	cached, ok := c.readCatalog(ctx).Roles[accountID]
+	if ok && !c.catalog.options.Refresh && time.Since(cached.UpdatedAt) < c.catalog.options.TTL {
+		logger.Debug().Msgf("Using %d cached roles for account %s", len(cached.Roles), accountID)
+		return &sso.ListAccountRolesOutput{RoleList: cached.Roles}, nil
+	}
+
+	roles, err := c.listAvailableRoles(ctx, accountID, accessToken)
+	if err != nil {
+		if ok && c.cacheFallback() {
+			logger.Warn().Msgf("Using cached roles for account %s, listing failed: %v", accountID, err)
+			return &sso.ListAccountRolesOutput{RoleList: cached.Roles}, nil
+		}
+		return roles, err
+	}
+	c.updateCatalog(ctx, func(cat *accountCatalog) {
+		cat.Roles[accountID] = cachedRoles{UpdatedAt: time.Now(), Roles: roles.RoleList}
+	})
+	return roles, nil
+}
+
+// catalogAccountRoles returns a pair per cached account and role for the combined picker
+// Accounts without cached roles have a pair with an empty role.
+// Nothing is returned when no roles are cached or the accounts are older than the ttl, so accounts are listed again.
+func (c *Client) catalogAccountRoles(ctx context.Context) []terminal.AccountRole {
+	if c.catalog == nil || c.catalog.options.Refresh {
+		return nil
+	}
+	cat := c.readCatalog(ctx)
+	if len(cat.Roles) == 0 || time.Since(cat.AccountsUpdatedAt) >= c.catalog.options.TTL {
+		return nil
+	}
+
+	var pairs []terminal.AccountRole
+	for _, account := range cat.Accounts {
+		cached, ok := cat.Roles[aws.ToString(account.AccountId)]
+		if !ok || len(cached.Roles) == 0 {
+			pairs = append(pairs, terminal.AccountRole{Account: account})
+			continue
+		}
+		for _, role := range cached.Roles {
+			pairs = append(pairs, terminal.AccountRole{Account: account, Role: role})
+		}
+	}
+	return pairs
+}
+
+// readCatalog returns the cached catalog or an empty catalog
+func (c *Client) readCatalog(ctx context.Context) accountCatalog {
+	logger := zerolog.Ctx(ctx)
+	cat := accountCatalog{StartURL: c.catalog.options.StartURL, Roles: map[string]cachedRoles{}}
+
+	content, err := os.ReadFile(credentialsCacheDestination(catalogCacheKey(c.catalog.options.StartURL)))
+	if err != nil {
+		return cat
+	}
+	if err := json.Unmarshal(content, &cat); err != nil {
+		logger.Debug().Msgf("Ignoring unreadable catalog: %v", err)
+		return accountCatalog{StartURL: c.catalog.options.StartURL, Roles: map[string]cachedRoles{}}
+	}
+	if cat.Roles == nil {
+		cat.Roles = map[string]cachedRoles{}
+	}
+	return cat
+}
+
+// updateCatalog applies update to the cached catalog and writes it
+func (c *Client) updateCatalog(ctx context.Context, update func(*accountCatalog)) {
+	if c.catalog == nil {
+		return
+	}
+	c.catalog.mu.Lock()
+	defer c.catalog.mu.Unlock()
+
+	cat := c.readCatalog(ctx)
+	update(&cat)
+	writeStructToFile(ctx, &cat, credentialsCacheDestination(catalogCacheKey(c.catalog.options.StartURL)))
+}
+
+// catalogCacheKey returns the cache key of the catalog for a start url
+func catalogCacheKey(startURL string) string {
+	return "catalog-" + startURL
+}
@@ -0,0 +1,188 @@
+package amazon
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+
+	"ssoctx/internal/terminal"
+)
+
+const testCatalogURL = "https://d-1234567890.awsapps.com/start"
+
+// catalogClient returns a client with the catalog enabled, listing listed or failing when listed is nil
+func catalogClient(listed []types.AccountInfo, refresh bool, calls *int) *Client {
+	c := NewSSOClient(&mockSSOClient{
+		ListAccountsAPI: func(ctx context.Context, params *sso.ListAccountsInput, optFns ...func(*sso.Options)) (*sso.ListAccountsOutput, error) {
+			*calls++
+			if listed == nil {
+				return nil, errors.New("portal unreachable")
+			}
+			return &sso.ListAccountsOutput{AccountList: listed}, nil
+		},
+		ListAccountRolesAPI: func(ctx context.Context, params *sso.ListAccountRolesInput, optFns ...func(*sso.Options)) (*sso.ListAccountRolesOutput, error) {
+			*calls++
+			if listed == nil {
+				return nil, errors.New("portal unreachable")
+			}
+			return &sso.ListAccountRolesOutput{RoleList: []types.RoleInfo{{RoleName: aws.String("Listed")}}}, nil
+		},
+	})
+	c.UseCatalog(CatalogOptions{StartURL: testCatalogURL, TTL: time.Hour, Refresh: refresh})
+	return c
+}
+
+// seedCatalog writes the first account of testAccounts to the catalog, updated age ago
+func seedCatalog(c *Client, age time.Duration) {
+	c.updateCatalog(zerologTestingContext, func(cat *accountCatalog) {
+		cat.Accounts = testAccounts()[:1]
+		cat.AccountsUpdatedAt = time.Now().Add(-age)
+		cat.Roles["111111111111"] = cachedRoles{UpdatedAt: time.Now().Add(-age), Roles: []types.RoleInfo{{RoleName: aws.String("Cached")}}}
+	})
+}
+
+func TestPickAccountCatalog(t *testing.T) {
+	tests := []struct {
+		name      string
+		seedAge   time.Duration // no seed when 0
+		listed    []types.AccountInfo
+		refresh   bool
+		wantCalls int
+		wantKeys  int
+		wantErr   bool
+		wantSaved int
+	}{
+		{"fresh cache", time.Minute, testAccounts(), false, 0, 1, false, 1},
+		{"stale cache", 2 * time.Hour, testAccounts(), false, 1, 4, false, 3},
+		{"stale cache offline", 2 * time.Hour, nil, false, 1, 1, true, 1},
+		{"no cache", 0, testAccounts(), false, 1, 3, false, 3},
+		{"refresh", time.Minute, testAccounts(), true, 1, 3, false, 3},
+		{"refresh offline", time.Minute, nil, true, 1, 1, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempCredentialsCache(t)
+			original := selectAccountStream
+			defer func() { selectAccountStream = original }()
+
+			calls := 0
+			c := catalogClient(tt.listed, tt.refresh, &calls)
+			if tt.seedAge > 0 {
+				seedCatalog(c, tt.seedAge)
+			}
+
+			var (
+				options int
+				pageErr error
+			)
+			selectAccountStream = func(pages <-chan terminal.OptionPage[types.AccountInfo], title string) (types.AccountInfo, error) {
+				for page := range pages {
+					options += len(page.Options)
+					if page.Err != nil {
+						pageErr = page.Err
+					}
+				}
+				return types.AccountInfo{AccountId: aws.String("111111111111")}, nil
+			}
+
+			if _, err := pickAccount(zerologTestingContext, c, "token"); err != nil {
+				t.Fatalf("pickAccount() error = %v", err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("pickAccount() listed %d times, want %d", calls, tt.wantCalls)
+			}
+			if options != tt.wantKeys || (pageErr != nil) != tt.wantErr {
+				t.Errorf("pickAccount() streamed %d options, err %v, want %d, wantErr %v", options, pageErr, tt.wantKeys, tt.wantErr)
+			}
+			if saved := len(c.readCatalog(zerologTestingContext).Accounts); saved != tt.wantSaved {
+				t.Errorf("catalog has %d accounts, want %d", saved, tt.wantSaved)
+			}
+		})
+	}
+}
+
+func TestPickAccountRefreshesAfterPick(t *testing.T) {
+	useTempCredentialsCache(t)
+	original := selectAccountStream
+	defer func() { selectAccountStream = original }()
+
+	calls := 0
+	c := catalogClient(testAccounts(), false, &calls)
+	seedCatalog(c, 2*time.Hour)
+	// the account is picked from the stale cache before listing is done
+	selectAccountStream = func(pages <-chan terminal.OptionPage[types.AccountInfo], title string) (types.AccountInfo, error) {
+		page := <-pages
+		return page.Options[0].Value, nil
+	}
+
+	if _, err := pickAccount(zerologTestingContext, c, "token"); err != nil {
+		t.Fatalf("pickAccount() error = %v", err)
+	}
+	if saved := len(c.readCatalog(zerologTestingContext).Accounts); saved != 3 {
+		t.Errorf("catalog has %d accounts after the pick, want the 3 listed", saved)
+	}
+}
+
+func TestClient_CatalogRoles(t *testing.T) {
+	tests := []struct {
+		name      string
+		seedAge   time.Duration
+		listed    []types.AccountInfo
+		want      string
+		wantCalls int
+		wantErr   bool
+	}{
+		{"fresh cache", time.Minute, testAccounts(), "Cached", 0, false},
+		{"stale cache", 2 * time.Hour, testAccounts(), "Listed", 1, false},
+		{"stale cache offline", 2 * time.Hour, nil, "Cached", 1, false},
+		{"no cache offline", 0, nil, "", 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempCredentialsCache(t)
+			calls := 0
+			c := catalogClient(tt.listed, false, &calls)
+			if tt.seedAge > 0 {
+				seedCatalog(c, tt.seedAge)
+			}
+
+			roles, err := c.catalogRoles(zerologTestingContext, "111111111111", "token")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("catalogRoles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("catalogRoles() listed %d times, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := *roles.RoleList[0].RoleName; got != tt.want {
+				t.Errorf("catalogRoles() = %v, want %v", got, tt.want)
+			}
+			cached := c.readCatalog(zerologTestingContext).Roles["111111111111"].Roles
+			if *cached[0].RoleName != tt.want {
+				t.Errorf("catalog roles = %v, want %v", *cached[0].RoleName, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_ListAccountsCatalogFallback(t *testing.T) {
+	useTempCredentialsCache(t)
+	calls := 0
+	c := catalogClient(nil, false, &calls)
+	if _, err := c.listAccounts(zerologTestingContext, "token"); err == nil {
+		t.Errorf("listAccounts() without cache should fail")
+	}
+
+	seedCatalog(c, 2*time.Hour)
+	output, err := c.listAccounts(zerologTestingContext, "token")
+	if err != nil || len(output.AccountList) != 1 {
+		t.Errorf("listAccounts() = %v, %v, want cached accounts", output.AccountList, err)
+	}
+}
@@ -0,0 +1,194 @@
+package amazon
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/user"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	stsTypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/rs/zerolog"
+
+	"ssoctx/internal/file"
+	"ssoctx/internal/terminal"
+)
+
+const (
+	defaultSessionName = ProjectFileName + "-{{.User}}"
+	maxSessionName     = 64
+)
+
+var (
+	newSTSClient = func(region string, creds aws.CredentialsProvider) STSClient {
+		return sts.New(sts.Options{Region: serviceRegion(region), Credentials: creds})
+	}
+	promptMFACode = func(serial string) (string, error) {
+		return terminal.NewInputForm(fmt.Sprintf("MFA code for %s", serial))
+	}
+	currentUser = user.Current
+
+	invalidSessionNameChars = regexp.MustCompile(`[^\w+=,.@-]`)
+)
+
+// STSClient is used to abstract the client calls for mock testing
+type STSClient interface {
+	AssumeRole(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error)
+}
+
+// sessionNameData is used to render the session name template of a role chain
+type sessionNameData struct {
+	AccountID string
+	RoleName  string
+	Chain     string
+	User      string
+}
+
+// resolveChainCredentials returns cached credentials for the chain
+// When nothing valid is cached, the chained role is assumed from the SSO role credentials and cached
+// Cached credentials are kept for at most maxDuration, when set.
+func resolveChainCredentials(ctx context.Context, chain *file.RoleChain, startURL, region string, maxDuration time.Duration, ssoCredentials func() (*sso.GetRoleCredentialsOutput, error)) (*sso.GetRoleCredentialsOutput, error) {
+	logger := zerolog.Ctx(ctx)
+	key := chainCacheKey(startURL, chain)
+
+	if cached, ok := readCachedCredentials(ctx, key, maxDuration); ok {
+		logger.Debug().Msgf("Using cached credentials for chain %s", chain.Name)
+		return cached, nil
+	}
+
+	roleCredentials, err := ssoCredentials()
+	if err != nil {
+		return &sso.GetRoleCredentialsOutput{}, err
+	}
+	chained, err := assumeChainedRole(ctx, chain, region, roleCredentials)
+	if err != nil {
+		return &sso.GetRoleCredentialsOutput{}, err
+	}
+	writeCachedCredentials(ctx, key, chained, maxDuration)
+	return chained, nil
+}
+
+// assumeChainedRole assumes the target role of the chain using the SSO role credentials
+func assumeChainedRole(ctx context.Context, chain *file.RoleChain, region string, creds *sso.GetRoleCredentialsOutput) (*sso.GetRoleCredentialsOutput, error) {
+	logger := zerolog.Ctx(ctx)
+
+	sessionName, err := chainSessionName(chain)
+	if err != nil {
+		return &sso.GetRoleCredentialsOutput{}, err
+	}
+	input := &sts.AssumeRoleInput{
+		RoleArn:         aws.String(chain.RoleARN),
+		RoleSessionName: aws.String(sessionName),
+	}
+	if chain.Duration > 0 {
+		input.DurationSeconds = aws.Int32(int32(chain.Duration.Seconds()))
+	}
+	if len(chain.ExternalID) > 0 {
+		input.ExternalId = aws.String(chain.ExternalID)
+	}
+	if len(chain.SourceIdentity) > 0 {
+		input.SourceIdentity = aws.String(chain.SourceIdentity)
+	}
+	if len(chain.MFASerial) > 0 {
+		code, err := promptMFACode(chain.MFASerial)
+		if err != nil {
+			return &sso.GetRoleCredentialsOutput{}, err
+		}
+		input.SerialNumber = aws.String(chain.MFASerial)
+		input.TokenCode = aws.String(strings.TrimSpace(code))
+	}
+	input.Tags = chainSessionTags(chain.Tags)
+
+	logger.Debug().Msgf("Assuming %s as session %s for chain %s", chain.RoleARN, sessionName, chain.Name)
+	output, err := newSTSClient(region, roleCredentialsProvider(creds)).AssumeRole(ctx, input)
+	if err != nil {
+		// pass through for debug
+		_ = GetAWSErrorCode(ctx, err)
+		return &sso.GetRoleCredentialsOutput{}, err
+	}
+	if output.Credentials == nil {
+		return &sso.GetRoleCredentialsOutput{}, errors.New("AssumeRole returned no credentials")
+	}
+
+	return &sso.GetRoleCredentialsOutput{
+		RoleCredentials: &types.RoleCredentials{
+			AccessKeyId:     output.Credentials.AccessKeyId,
+			SecretAccessKey: output.Credentials.SecretAccessKey,
+			SessionToken:    output.Credentials.SessionToken,
+			Expiration:      aws.ToTime(output.Credentials.Expiration).UnixMilli(),
+		},
+	}, nil
+}
+
+// chainSessionName renders the session name template of the chain
+// Characters not allowed by sts are replaced and the name is truncated to the max length
+func chainSessionName(chain *file.RoleChain) (string, error) {
+	text := chain.SessionName
+	if len(text) == 0 {
+		text = defaultSessionName
+	}
+	tmpl, err := template.New("session-name").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid session name template for chain %s: %w", chain.Name, err)
+	}
+
+	data := sessionNameData{
+		AccountID: chain.AccountID,
+		RoleName:  chain.RoleName,
+		Chain:     chain.Name,
+		User:      "unknown",
+	}
+	if u, err := currentUser(); err == nil {
+		data.User = u.Username
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("unable to render session name for chain %s: %w", chain.Name, err)
+	}
+	name := invalidSessionNameChars.ReplaceAllString(b.String(), "-")
+	if len(name) > maxSessionName {
+		name = name[:maxSessionName]
+	}
+	if len(name) < 2 {
+		return "", fmt.Errorf("session name %q for chain %s is too short", name, chain.Name)
+	}
+	return name, nil
+}
+
+// chainSessionTags returns the session tags sorted by key
+func chainSessionTags(tags map[string]string) []stsTypes.Tag {
+	if len(tags) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sessionTags := make([]stsTypes.Tag, len(keys))
+	for i, key := range keys {
+		sessionTags[i] = stsTypes.Tag{Key: aws.String(key), Value: aws.String(tags[key])}
+	}
+	return sessionTags
+}
+
+// chainCacheKey returns the credentials cache key for a chain of a start url
+// The key has a hash of the start url and the chain definition, so changing the source or target role
+// never serves credentials cached for the old definition.
+func chainCacheKey(startURL string, chain *file.RoleChain) string {
+	definition, _ := json.Marshal(chain)
+	sum := sha256.Sum256(append([]byte(startURL+"\n"), definition...))
+	return fmt.Sprintf("chain-%s-%x", chain.Name, sum[:8])
+}
@@ -0,0 +1,220 @@
+package amazon
+
+import (
+	"context"
+	"errors"
+	"os/user"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	stsTypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+
+	"ssoctx/internal/file"
+)
+
+type mockSTSClient struct {
+	AssumeRoleAPI func(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error)
+}
+
+func (m *mockSTSClient) AssumeRole(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	if m.AssumeRoleAPI != nil {
+		return m.AssumeRoleAPI(ctx, params, optFns...)
+	}
+	return nil, nil
+}
+
+func useMockSTSClient(t *testing.T, client STSClient) {
+	t.Helper()
+	original := newSTSClient
+	newSTSClient = func(region string, creds aws.CredentialsProvider) STSClient {
+		return client
+	}
+	t.Cleanup(func() { newSTSClient = original })
+}
+
+func TestChainSessionName(t *testing.T) {
+	original := currentUser
+	defer func() { currentUser = original }()
+	currentUser = func() (*user.User, error) {
+		return &user.User{Username: `CORP\jdoe`}, nil
+	}
+
+	tests := []struct {
+		name    string
+		chain   file.RoleChain
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "Default session name",
+			chain: file.RoleChain{Name: "admin"},
+			want:  "ssoctx-CORP-jdoe",
+		},
+		{
+			name:  "Template with fields",
+			chain: file.RoleChain{Name: "admin", AccountID: "111111111111", RoleName: "Dev", SessionName: "{{.Chain}}.{{.RoleName}}@{{.AccountID}}"},
+			want:  "admin.Dev@111111111111",
+		},
+		{
+			name:  "Long names are truncated",
+			chain: file.RoleChain{Name: "admin", SessionName: strings.Repeat("a", 80)},
+			want:  strings.Repeat("a", 64),
+		},
+		{
+			name:    "Invalid template",
+			chain:   file.RoleChain{Name: "admin", SessionName: "{{.Nope"},
+			wantErr: true,
+		},
+		{
+			name:    "Too short",
+			chain:   file.RoleChain{Name: "admin", SessionName: "a"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := chainSessionName(&tt.chain)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("chainSessionName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("chainSessionName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssumeChainedRole(t *testing.T) {
+	expiration := time.Now().Add(time.Hour).Truncate(time.Second)
+	chain := &file.RoleChain{
+		Name:           "admin",
+		AccountID:      "111111111111",
+		RoleName:       "Developer",
+		RoleARN:        "arn:aws:iam::222222222222:role/Admin",
+		SessionName:    "session",
+		Duration:       30 * time.Minute,
+		ExternalID:     "external",
+		SourceIdentity: "jdoe",
+		MFASerial:      "arn:aws:iam::111111111111:mfa/jdoe",
+		Tags:           map[string]string{"team": "platform", "env": "prod"},
+	}
+
+	originalPrompt := promptMFACode
+	defer func() { promptMFACode = originalPrompt }()
+	promptMFACode = func(serial string) (string, error) {
+		return " 123456 ", nil
+	}
+
+	tests := []struct {
+		name    string
+		client  STSClient
+		wantErr bool
+	}{
+		{
+			name: "AssumeRole success",
+			client: &mockSTSClient{
+				AssumeRoleAPI: func(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+					if *params.RoleArn != chain.RoleARN || *params.RoleSessionName != "session" ||
+						*params.DurationSeconds != 1800 || *params.ExternalId != "external" ||
+						*params.SourceIdentity != "jdoe" || *params.TokenCode != "123456" ||
+						*params.SerialNumber != chain.MFASerial || *params.Tags[0].Key != "env" {
+						return nil, errors.New("unexpected input")
+					}
+					return &sts.AssumeRoleOutput{
+						Credentials: &stsTypes.Credentials{
+							AccessKeyId:     aws.String("ASIACHAINED"),
+							SecretAccessKey: aws.String("secret"),
+							SessionToken:    aws.String("token"),
+							Expiration:      &expiration,
+						},
+					}, nil
+				},
+			},
+		},
+		{
+			name: "AssumeRole error",
+			client: &mockSTSClient{
+				AssumeRoleAPI: func(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+					return nil, errors.New("access denied")
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useMockSTSClient(t, tt.client)
+			got, err := assumeChainedRole(zerologTestingContext, chain, "us-west-2", fakeRoleCredentials())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("assumeChainedRole() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if *got.RoleCredentials.AccessKeyId != "ASIACHAINED" || got.RoleCredentials.Expiration != expiration.UnixMilli() {
+				t.Errorf("assumeChainedRole() = %v", got.RoleCredentials)
+			}
+		})
+	}
+}
+
+func TestResolveChainCredentials(t *testing.T) {
+	useTempCredentialsCache(t)
+	expiration := time.Now().Add(time.Hour)
+	calls := 0
+	useMockSTSClient(t, &mockSTSClient{
+		AssumeRoleAPI: func(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+			calls++
+			return &sts.AssumeRoleOutput{
+				Credentials: &stsTypes.Credentials{
+					AccessKeyId:     aws.String("ASIACHAINED"),
+					SecretAccessKey: aws.String("secret"),
+					SessionToken:    aws.String("token"),
+					Expiration:      &expiration,
+				},
+			}, nil
+		},
+	})
+	ssoCredentials := func() (*sso.GetRoleCredentialsOutput, error) {
+		return fakeRoleCredentials(), nil
+	}
+	chain := &file.RoleChain{Name: "admin", RoleARN: "arn:aws:iam::222222222222:role/Admin", SessionName: "session"}
+
+	for i := 0; i < 2; i++ {
+		got, err := resolveChainCredentials(zerologTestingContext, chain, testCatalogURL, "us-west-2", 0, ssoCredentials)
+		if err != nil {
+			t.Fatalf("resolveChainCredentials() error = %v", err)
+		}
+		if *got.RoleCredentials.AccessKeyId != "ASIACHAINED" {
+			t.Errorf("resolveChainCredentials() access key = %v", *got.RoleCredentials.AccessKeyId)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("resolveChainCredentials() assumed role %d times, want cached after first", calls)
+	}
+
+	// a changed target role or start url is not served the cached credentials
+	changed := *chain
+	changed.RoleARN = "arn:aws:iam::222222222222:role/ReadOnly"
+	if _, err := resolveChainCredentials(zerologTestingContext, &changed, testCatalogURL, "us-west-2", 0, ssoCredentials); err != nil {
+		t.Fatalf("resolveChainCredentials() error = %v", err)
+	}
+	if _, err := resolveChainCredentials(zerologTestingContext, chain, otherStartURL, "us-west-2", 0, ssoCredentials); err != nil {
+		t.Fatalf("resolveChainCredentials() error = %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("resolveChainCredentials() assumed role %d times, want again for the changed chain and start url", calls)
+	}
+
+	// sso errors are passed through
+	_, err := resolveChainCredentials(zerologTestingContext, &file.RoleChain{Name: "other"}, testCatalogURL, "us-west-2", 0, func() (*sso.GetRoleCredentialsOutput, error) {
+		return nil, errors.New("forbidden")
+	})
+	if err == nil {
+		t.Errorf("resolveChainCredentials() expected sso error")
+	}
+}
@@ -0,0 +1,146 @@
+package amazon
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/rs/zerolog"
+
+	"ssoctx/internal/file"
+)
+
+const (
+	codecommitSigningName = "codecommit"
+	sigV4Algorithm        = "AWS4-HMAC-SHA256"
+)
+
+var codecommitHostPattern = regexp.MustCompile(`^git-codecommit(-fips)?\.([a-z0-9-]+)\.amazonaws\.com(\.cn)?$`)
+
+// GitCredentialFlagInputs contains all needed inputs for GitCredentialHelper
+type GitCredentialFlagInputs struct {
+	Action       string // get, store or erase
+	StartURL     string
+	Repositories []file.CodeCommitRepo
+}
+
+// GitCredentialHelper implements the git credential helper protocol for CodeCommit repositories
+// The request is read from in and the response written to out
+// The derived password is only written to out and never stored, so store and erase are no-ops
+func GitCredentialHelper(ctx context.Context, o *OIDCClientAPI, s *Client, inputs GitCredentialFlagInputs, in io.Reader, out io.Writer) error {
+	logger := zerolog.Ctx(ctx)
+
+	attributes, err := readGitCredentialAttributes(in)
+	if err != nil {
+		return err
+	}
+	if inputs.Action != "get" {
+		return nil
+	}
+
+	host := attributes["host"]
+	matches := codecommitHostPattern.FindStringSubmatch(host)
+	if attributes["protocol"] != "https" || matches == nil {
+		logger.Debug().Msgf("%s is not a codecommit https host", host)
+		return nil
+	}
+	region := matches[2]
+	repoPath := "/" + strings.TrimPrefix(attributes["path"], "/")
+
+	repo, ok := matchCodeCommitRepo(inputs.Repositories, region, path.Base(repoPath))
+	if !ok {
+		logger.Debug().Msgf("No codecommit account configured for %s%s", host, repoPath)
+		return nil
+	}
+
+	if err := checkStartURL(s.policy, inputs.StartURL); err != nil {
+		return err
+	}
+	if err := checkPolicy(ctx, s, "", repo.AccountID, repo.RoleName, "", file.OutputProcess); err != nil {
+		return err
+	}
+	roleCredentials, err := cachedRoleCredentials(ctx, o, s, repo.AccountID, repo.RoleName, inputs.StartURL)
+	if err != nil {
+		return err
+	}
+	username, password := codecommitCredentials(roleCredentials, region, host, repoPath, time.Now())
+	_, err = fmt.Fprintf(out, "protocol=https\nhost=%s\nusername=%s\npassword=%s\n", host, username, password)
+	return err
+}
+
+// matchCodeCommitRepo returns the first configured repository matching the region and repository name
+// Without credential.useHttpPath git does not send the path, so only entries without a repository match
+func matchCodeCommitRepo(repos []file.CodeCommitRepo, region, name string) (file.CodeCommitRepo, bool) {
+	for _, repo := range repos {
+		if len(repo.Region) > 0 && repo.Region != region {
+			continue
+		}
+		if len(repo.Repository) > 0 {
+			if matched, _ := path.Match(repo.Repository, name); !matched {
+				continue
+			}
+		}
+		return repo, true
+	}
+	return file.CodeCommitRepo{}, false
+}
+
+// codecommitCredentials derives the git username and password from the role credentials
+// This is the same SigV4 signature of the GIT request the aws cli credential helper creates
+func codecommitCredentials(creds *sso.GetRoleCredentialsOutput, region, host, repoPath string, signingTime time.Time) (string, string) {
+	timestamp := signingTime.UTC().Format("20060102T150405")
+	date := timestamp[:8]
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, region, codecommitSigningName)
+
+	canonicalRequest := fmt.Sprintf("GIT\n%s\n\nhost:%s\n\nhost\n", repoPath, host)
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := fmt.Sprintf("%s\n%s\n%s\n%s", sigV4Algorithm, timestamp, scope, hex.EncodeToString(canonicalHash[:]))
+
+	key := []byte("AWS4" + *creds.RoleCredentials.SecretAccessKey)
+	for _, part := range []string{date, region, codecommitSigningName, "aws4_request", stringToSign} {
+		key = hmacSHA256(key, part)
+	}
+
+	username := *creds.RoleCredentials.AccessKeyId
+	if token := creds.RoleCredentials.SessionToken; token != nil && len(*token) > 0 {
+		username += "%" + *token
+	}
+	return username, timestamp + "Z" + hex.EncodeToString(key)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// readGitCredentialAttributes reads the key=value lines sent by git until a blank line
+func readGitCredentialAttributes(in io.Reader) (map[string]string, error) {
+	attributes := map[string]string{}
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) == 0 {
+			break
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid git credential attribute %q", line)
+		}
+		attributes[key] = value
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return attributes, nil
+}
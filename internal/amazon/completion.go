@@ -0,0 +1,91 @@
+package amazon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	ini "gopkg.in/ini.v1"
+)
+
+// awsConfigFilePath returns the aws config file path, replaced in tests
+var awsConfigFilePath = func() string {
+	if path := os.Getenv("AWS_CONFIG_FILE"); len(path) > 0 {
+		return path
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".aws", "config")
+}
+
+// CachedAccounts returns the cached accounts of the start url sorted by name
+// Nothing is listed and no login is started, so it is safe to use for shell completion.
+func CachedAccounts(ctx context.Context, startURL string) []types.AccountInfo {
+	c := NewSSOClient(nil)
+	c.UseCatalog(CatalogOptions{StartURL: startURL})
+	accounts, _ := c.catalogAccounts(ctx)
+	sort.SliceStable(accounts, func(i, j int) bool {
+		return aws.ToString(accounts[i].AccountName) < aws.ToString(accounts[j].AccountName)
+	})
+	return accounts
+}
+
+// CachedRoles returns the cached role names of the account, or of every cached account when accountID is empty
+// Nothing is listed and no login is started, so it is safe to use for shell completion.
+func CachedRoles(ctx context.Context, startURL, accountID string) []string {
+	c := NewSSOClient(nil)
+	c.UseCatalog(CatalogOptions{StartURL: startURL})
+	if c.catalog == nil {
+		return nil
+	}
+	cat := c.readCatalog(ctx)
+
+	seen := map[string]bool{}
+	var roles []string
+	for id, cached := range cat.Roles {
+		if len(accountID) > 0 && id != accountID {
+			continue
+		}
+		for _, role := range cached.Roles {
+			if name := aws.ToString(role.RoleName); !seen[name] {
+				seen[name] = true
+				roles = append(roles, name)
+			}
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// ProfileNames returns the sorted profile names of the aws credentials and config files
+func ProfileNames() []string {
+	seen := map[string]bool{}
+	var profiles []string
+	add := func(name string) {
+		if len(name) > 0 && name != ini.DefaultSection && !seen[name] {
+			seen[name] = true
+			profiles = append(profiles, name)
+		}
+	}
+
+	if credentials, err := ini.Load(getCredentialsFilePath()); err == nil {
+		for _, section := range credentials.SectionStrings() {
+			add(section)
+		}
+	}
+	if config, err := ini.Load(awsConfigFilePath()); err == nil {
+		for _, section := range config.SectionStrings() {
+			// the config file prefixes profiles, other sections like sso-session are not profiles
+			if name, ok := strings.CutPrefix(section, "profile "); ok {
+				add(strings.TrimSpace(name))
+			} else if section == "default" {
+				add(section)
+			}
+		}
+	}
+	sort.Strings(profiles)
+	return profiles
+}
@@ -0,0 +1,68 @@
+package amazon
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+)
+
+func TestCachedAccountsAndRoles(t *testing.T) {
+	useTempCredentialsCache(t)
+
+	if got := CachedAccounts(zerologTestingContext, testCatalogURL); len(got) != 0 {
+		t.Errorf("CachedAccounts() without catalog = %v, want none", got)
+	}
+
+	calls := 0
+	c := catalogClient(nil, false, &calls)
+	c.updateCatalog(zerologTestingContext, func(cat *accountCatalog) {
+		cat.Accounts = testAccounts()
+		cat.AccountsUpdatedAt = time.Now().Add(-24 * time.Hour)
+		cat.Roles["111111111111"] = cachedRoles{Roles: []types.RoleInfo{{RoleName: aws.String("ReadOnly")}, {RoleName: aws.String("Admin")}}}
+		cat.Roles["222222222222"] = cachedRoles{Roles: []types.RoleInfo{{RoleName: aws.String("Developer")}, {RoleName: aws.String("ReadOnly")}}}
+	})
+
+	var names []string
+	for _, account := range CachedAccounts(zerologTestingContext, testCatalogURL) {
+		names = append(names, aws.ToString(account.AccountName))
+	}
+	if want := []string{"dev-app", "prod-app", "prod-data"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("CachedAccounts() = %v, want stale accounts sorted by name %v", names, want)
+	}
+	if got, want := CachedRoles(zerologTestingContext, testCatalogURL, "111111111111"), []string{"Admin", "ReadOnly"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CachedRoles() of account = %v, want %v", got, want)
+	}
+	if got, want := CachedRoles(zerologTestingContext, testCatalogURL, ""), []string{"Admin", "Developer", "ReadOnly"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CachedRoles() of every account = %v, want %v", got, want)
+	}
+	if calls != 0 {
+		t.Errorf("listed %d times, want cache only", calls)
+	}
+}
+
+func TestProfileNames(t *testing.T) {
+	dir := t.TempDir()
+	credentials := filepath.Join(dir, "credentials")
+	config := filepath.Join(dir, "config")
+	if err := os.WriteFile(credentials, []byte("[default]\naws_access_key_id = x\n[work]\nregion = us-east-1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(config, []byte("[default]\nregion = us-east-1\n[profile audit]\nregion = us-east-1\n[profile work]\n[sso-session corp]\nsso_region = us-east-1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	mockGetCredentialsFilePath = func() string { return credentials }
+	defer func() { mockGetCredentialsFilePath = nil }()
+	originalConfig := awsConfigFilePath
+	defer func() { awsConfigFilePath = originalConfig }()
+	awsConfigFilePath = func() string { return config }
+
+	if got, want := ProfileNames(), []string{"audit", "default", "work"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ProfileNames() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,204 @@
+package amazon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/rs/zerolog"
+
+	"ssoctx/internal/file"
+)
+
+var (
+	federationClient = &http.Client{Timeout: 30 * time.Second}
+	signinEndpoint   = partitionSigninEndpoint
+)
+
+// ConsoleFlagInputs contains all needed inputs for Console
+type ConsoleFlagInputs struct {
+	AccountID       string
+	RoleName        string
+	StartURL        string
+	Region          string
+	Destination     string            // console path or full console url to land on
+	Browser         string            // browser command used when opening a browser profile
+	BrowserProfile  string            // overrides the browser profile for the account
+	BrowserProfiles map[string]string // browser profile per account id from config
+	Print           bool
+}
+
+// federationSession is the session payload expected by getSigninToken
+type federationSession struct {
+	SessionID    string `json:"sessionId"`
+	SessionKey   string `json:"sessionKey"`
+	SessionToken string `json:"sessionToken"`
+}
+
+// federationSigninToken is the response payload of getSigninToken
+type federationSigninToken struct {
+	SigninToken string `json:"SigninToken"`
+}
+
+// Console is used to open the AWS console for the selected account and role
+// Role credentials are exchanged for a federated sign-in url through getSigninToken
+func Console(ctx context.Context, o *OIDCClientAPI, s *Client, inputs ConsoleFlagInputs) {
+	logger := zerolog.Ctx(ctx)
+	enforceStartURL(ctx, s, inputs.StartURL)
+
+	destination := clientInfoFileDestination(inputs.StartURL)
+	clientInformation, err := o.processClientInformation(ctx, destination)
+	if err != nil {
+		logger.Fatal().Msgf("Encountered error in processClientInformation: %v", err)
+	}
+	writeSecretToFile(ctx, &clientInformation, destination)
+
+	checkAccountID(ctx, s, clientInformation.AccessToken, inputs.AccountID)
+	inputs.AccountID, inputs.RoleName = selectAccountAndRole(ctx, s, clientInformation.AccessToken, inputs.AccountID, inputs.RoleName)
+	enforcePolicy(ctx, s, clientInformation.AccessToken, inputs.AccountID, inputs.RoleName, "", file.OutputConsole)
+
+	roleCredentials, err := s.getRolesCredentials(ctx, inputs.AccountID, inputs.RoleName, clientInformation.AccessToken)
+	if err = checkRoleCredentialsError(ctx, s, clientInformation.AccessToken, inputs.AccountID, inputs.RoleName, err); err != nil {
+		logger.Fatal().Msgf("Encountered error attempting to getRoleCredentials: %v", err)
+	}
+
+	signinURL, err := consoleSigninURL(ctx, roleCredentials, inputs.Region, inputs.Destination)
+	if err != nil {
+		logger.Fatal().Msgf("Encountered error creating console sign-in url: %v", err)
+	}
+
+	if inputs.Print {
+		fmt.Println(signinURL)
+		return
+	}
+
+	browserProfile := inputs.BrowserProfile
+	if len(browserProfile) == 0 {
+		browserProfile = inputs.BrowserProfiles[inputs.AccountID]
+	}
+
+	logger.Info().Msgf("Opening console for account %s with permission set role %s", s.describeAccount(inputs.AccountID), inputs.RoleName)
+	if len(browserProfile) > 0 {
+		err = openURLInBrowserProfile(inputs.Browser, browserProfile, signinURL)
+	} else {
+		err = openURLInBrowser(signinURL)
+	}
+	if err != nil {
+		logger.Fatal().Msgf("Encountered error opening console: %v", err)
+	}
+}
+
+// consoleSigninURL exchanges role credentials for a sign-in token and returns the login url
+func consoleSigninURL(ctx context.Context, creds *sso.GetRoleCredentialsOutput, region, destination string) (string, error) {
+	endpoint, consoleURL := signinEndpoint(region)
+
+	token, err := getSigninToken(ctx, endpoint, creds)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("Action", "login")
+	query.Set("Issuer", ProjectFileName)
+	query.Set("Destination", consoleDestination(consoleURL, region, destination))
+	query.Set("SigninToken", token)
+	return fmt.Sprintf("%s?%s", endpoint, query.Encode()), nil
+}
+
+// getSigninToken calls the federation endpoint to retrieve a sign-in token for the credentials
+func getSigninToken(ctx context.Context, endpoint string, creds *sso.GetRoleCredentialsOutput) (string, error) {
+	session, err := json.Marshal(federationSession{
+		SessionID:    *creds.RoleCredentials.AccessKeyId,
+		SessionKey:   *creds.RoleCredentials.SecretAccessKey,
+		SessionToken: *creds.RoleCredentials.SessionToken,
+	})
+	if err != nil {
+		return "", fmt.Errorf("encountered error in marshal of federation session: %w", err)
+	}
+
+	query := url.Values{}
+	query.Set("Action", "getSigninToken")
+	query.Set("Session", string(session))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s?%s", endpoint, query.Encode()), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := federationClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("encountered error calling federation endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("federation endpoint returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	token := federationSigninToken{}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("encountered error in unmarshal of sign-in token: %w", err)
+	}
+	if len(token.SigninToken) == 0 {
+		return "", errors.New("federation endpoint returned an empty sign-in token")
+	}
+	return token.SigninToken, nil
+}
+
+// consoleDestination builds the console url to land on after sign-in
+func consoleDestination(consoleURL, region, destination string) string {
+	if strings.HasPrefix(destination, "https://") {
+		return destination
+	}
+	if len(destination) == 0 {
+		destination = "console/home"
+	}
+	target := consoleURL + strings.TrimPrefix(destination, "/")
+	if len(region) == 0 || strings.Contains(target, "region=") {
+		return target
+	}
+	separator := "?"
+	if strings.Contains(target, "?") {
+		separator = "&"
+	}
+	return fmt.Sprintf("%s%sregion=%s", target, separator, region)
+}
+
+// partitionSigninEndpoint returns the federation endpoint and console url for the region partition
+func partitionSigninEndpoint(region string) (string, string) {
+	switch {
+	case strings.HasPrefix(region, "us-gov-"):
+		return "https://signin.amazonaws-us-gov.com/federation", "https://console.amazonaws-us-gov.com/"
+	case strings.HasPrefix(region, "cn-"):
+		return "https://signin.amazonaws.cn/federation", "https://console.amazonaws.cn/"
+	default:
+		return "https://signin.aws.amazon.com/federation", "https://console.aws.amazon.com/"
+	}
+}
+
+// openURLInBrowserProfile opens the url with a browser command using a separate browser profile
+// Firefox style browsers use -P while chromium based browsers use --profile-directory
+func openURLInBrowserProfile(browser, profile, url string) error {
+	if len(browser) == 0 {
+		return errors.New("a browser must be configured to use browser profiles")
+	}
+	args := []string{fmt.Sprintf("--profile-directory=%s", profile), url}
+	if strings.Contains(strings.ToLower(filepath.Base(browser)), "firefox") {
+		args = []string{"-P", profile, "-new-tab", url}
+	}
+	if err := execCmd(browser, args...).Start(); err != nil {
+		return fmt.Errorf("unable to open browser: %w", err)
+	}
+	return nil
+}
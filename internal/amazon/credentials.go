@@ -44,6 +44,7 @@ type CredentialsTemplate struct {
 	CredentialProcess  string `ini:"credential_process,omitempty"`
 	Output             string `ini:"output,omitempty"`
 	Region             string `ini:"region,omitempty"`
+	Managed            string `ini:"ssoctx_managed,omitempty"` // start url of profiles written by sync
 }
 
 // getPersistedCredentials returns a struct containing persisted creds values
@@ -70,6 +71,19 @@ func getCredentialProcess(accountID, roleName, region, startURL string) Credenti
 	}
 }
 
+// getChainCredentialProcess returns a struct containing credential process values for a role chain
+func getChainCredentialProcess(chainName, region, startURL string) CredentialsTemplate {
+	return CredentialsTemplate{
+		CredentialProcess: fmt.Sprintf(
+			"%s assume --chain %s -u %s",
+			ProjectFileName,
+			chainName,
+			startURL,
+		),
+		Region: region,
+	}
+}
+
 // // getCredentialsFilePath returns the credentials path
 // func getCredentialsFilePath() string {
 // 	homeDir, _ := os.UserHomeDir()
@@ -82,13 +96,24 @@ func getCredentialProcess(accountID, roleName, region, startURL string) Credenti
 // 	return filepath.Join(homeDir, ".aws", "sso", "cache", "access-token.json")
 // }
 
+// credentialsProfile is a template written to a profile of the credentials file
+type credentialsProfile struct {
+	Profile  string
+	Template CredentialsTemplate
+}
+
 // writeAWSCredentialsFile is used to write the template to credentials
 func writeAWSCredentialsFile(ctx context.Context, template *CredentialsTemplate, profile string) {
+	writeAWSCredentialsProfiles(ctx, []credentialsProfile{{Profile: profile, Template: *template}})
+}
+
+// writeAWSCredentialsProfiles is used to write every template to credentials in a single write
+func writeAWSCredentialsProfiles(ctx context.Context, profiles []credentialsProfile) {
 	if !exists(ctx, getCredentialsFilePath()) {
 		createCredentialsFile(ctx)
 	}
 	// Write to ini file
-	writeTemplateToFile(ctx, template, profile)
+	writeTemplatesToFile(ctx, profiles)
 }
 
 // readClientInformation is used to read file for ClientInformation
@@ -107,6 +132,7 @@ func readClientInformation(ctx context.Context, destination string) (ClientInfor
 }
 
 // writeStructToFile is used to write the payload to file
+// The payload is written to a temporary file that is renamed over dest, so readers never see a partial write.
 func writeStructToFile(ctx context.Context, payload interface{}, dest string) {
 	logger := zerolog.Ctx(ctx)
 	targetDir := filepath.Dir(dest)
@@ -119,11 +145,49 @@ func writeStructToFile(ctx context.Context, payload interface{}, dest string) {
 	if err != nil {
 		logger.Fatal().Msgf("Encountered error in marshal of payload: %q", err)
 	}
-	if err = os.WriteFile(dest, file, 0o644); err != nil {
+	if err = writeFileAtomically(dest, file, 0o644); err != nil {
 		logger.Fatal().Msgf("Encountered error trying to write file %s: %q", file, err)
 	}
 }
 
+// writeSecretToFile is used to write the payload to a file only the user can read
+// The payload is written to a temporary file that is renamed over dest, so readers never see a partial write.
+func writeSecretToFile(ctx context.Context, payload interface{}, dest string) {
+	logger := zerolog.Ctx(ctx)
+	if err := os.MkdirAll(filepath.Dir(dest), 0o700); err != nil {
+		logger.Fatal().Msgf("Encountered error in making dir: %q", err)
+	}
+	content, err := json.MarshalIndent(payload, "", " ")
+	if err != nil {
+		logger.Fatal().Msgf("Encountered error in marshal of payload: %q", err)
+	}
+	if err := writeFileAtomically(dest, content, 0o600); err != nil {
+		logger.Fatal().Msgf("Encountered error trying to write file %s: %q", dest, err)
+	}
+}
+
+// writeFileAtomically writes content to a temporary file next to dest with the mode and renames it over dest
+func writeFileAtomically(dest string, content []byte, mode os.FileMode) error {
+	temp, err := os.CreateTemp(filepath.Dir(dest), filepath.Base(dest)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if err := temp.Chmod(mode); err != nil {
+		temp.Close()
+		return err
+	}
+	if _, err := temp.Write(content); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), dest)
+}
+
 // exists checks either or not a target file is existing.
 // Returns true if the target exists, otherwise false.
 func exists(ctx context.Context, target string) bool {
@@ -139,35 +203,66 @@ func exists(ctx context.Context, target string) bool {
 
 // createCredentialsFile is used to create missing directories and file
 func createCredentialsFile(ctx context.Context) {
+	createProfileFile(ctx, getCredentialsFilePath())
+}
+
+// createProfileFile is used to create the missing directories and file of an aws profile file
+func createProfileFile(ctx context.Context, target string) {
 	logger := zerolog.Ctx(ctx)
-	dir := filepath.Dir(getCredentialsFilePath())
+	dir := filepath.Dir(target)
 	err := os.MkdirAll(dir, 0o755)
 	if err != nil {
 		logger.Fatal().Msgf("Encountered error in making dir: %q", err)
 	}
-	f, err := os.OpenFile(getCredentialsFilePath(), os.O_CREATE, 0o644)
+	f, err := os.OpenFile(target, os.O_CREATE, 0o644)
 	if err != nil {
 		logger.Fatal().Msgf("Encountered error opening file: %q", err)
 	}
 	defer f.Close()
 }
 
-// writeTemplateToFile loads credentials to replace and write new.
-// it calls replaceProfile to selectively delete a profile and replace it.
-// it then saves the changes over the credentials file.
-func writeTemplateToFile(ctx context.Context, template *CredentialsTemplate, profile string) {
+// writeTemplatesToFile loads credentials to replace and write new.
+// it calls replaceProfile to selectively delete each profile and replace it.
+// it then atomically replaces the credentials file, so readers never see some of the profiles written.
+func writeTemplatesToFile(ctx context.Context, profiles []credentialsProfile) {
 	logger := zerolog.Ctx(ctx)
 	creds, err := ini.Load(getCredentialsFilePath())
 	if err != nil {
 		logger.Fatal().Msgf("Encountered error loading credentials file: %q", err)
 	}
 
-	replaceProfile(ctx, creds, template, profile)
-	if err := creds.SaveTo(getCredentialsFilePath()); err != nil {
+	for i := range profiles {
+		replaceProfile(ctx, creds, &profiles[i].Template, profiles[i].Profile)
+	}
+	if err := saveAtomically(creds, getCredentialsFilePath()); err != nil {
 		logger.Fatal().Msgf("Encountered error saving credentials: %q", err)
 	}
 }
 
+// saveAtomically writes the ini file next to the destination and renames it over the destination
+// The mode of the existing destination is kept.
+func saveAtomically(creds *ini.File, destination string) error {
+	temp, err := os.CreateTemp(filepath.Dir(destination), filepath.Base(destination)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := creds.WriteTo(temp); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if info, err := os.Stat(destination); err == nil {
+		if err := os.Chmod(temp.Name(), info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	return os.Rename(temp.Name(), destination)
+}
+
 // replaceProfile is used to selectively delete a profile from the credentials file.
 // it then uses the new struct to populate the new information.
 func replaceProfile(ctx context.Context, creds *ini.File, template *CredentialsTemplate, profile string) {
@@ -170,3 +170,15 @@ func TestWriteAWSCredentialsFile(t *testing.T) {
 
 	writeAWSCredentialsFile(ctx, template, "default")
 }
+
+func TestGetChainCredentialProcess(t *testing.T) {
+	result := getChainCredentialProcess("workload-admin", "us-east-1", "https://d-123456abcd.awsapps.com/start")
+
+	expectedProcess := "ssoctx assume --chain workload-admin -u https://d-123456abcd.awsapps.com/start"
+	if result.CredentialProcess != expectedProcess {
+		t.Errorf("Expected CredentialProcess to be %s, got %s", expectedProcess, result.CredentialProcess)
+	}
+	if result.Region != "us-east-1" {
+		t.Errorf("Expected Region to be us-east-1, got %s", result.Region)
+	}
+}
@@ -0,0 +1,246 @@
+package amazon
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/rs/zerolog"
+
+	"ssoctx/internal/file"
+)
+
+// errDockerCredentialsNotFound is the message docker expects when a helper has no credentials
+var errDockerCredentialsNotFound = errors.New("credentials not found in native keychain")
+
+var (
+	newECRClient = func(region string, creds aws.CredentialsProvider) ECRClient {
+		return ecr.New(ecr.Options{Region: serviceRegion(region), Credentials: creds})
+	}
+
+	ecrRegistryPattern = regexp.MustCompile(`^(\d{12})\.dkr\.ecr(-fips)?\.([a-z0-9-]+)\.amazonaws\.com(\.cn)?$`)
+)
+
+// ECRClient is used to abstract the client calls for mock testing
+type ECRClient interface {
+	GetAuthorizationToken(ctx context.Context, params *ecr.GetAuthorizationTokenInput, optFns ...func(*ecr.Options)) (*ecr.GetAuthorizationTokenOutput, error)
+}
+
+// DockerCredentials is the payload of the docker credential helper protocol
+type DockerCredentials struct {
+	ServerURL string `json:"ServerURL,omitempty"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+}
+
+// DockerCredentialFlagInputs contains all needed inputs for DockerCredentialHelper
+type DockerCredentialFlagInputs struct {
+	Action      string // get, store, erase or list
+	StartURL    string
+	Region      string
+	DefaultRole string            // role used for accounts without a configured role
+	Roles       map[string]string // role name per account id
+}
+
+// ecrCacheEntry is used to cache an ecr authorization token until it expires
+type ecrCacheEntry struct {
+	Username  string
+	Secret    string
+	ExpiresAt time.Time
+	CachedAt  time.Time
+}
+
+// DockerCredentialHelper implements the docker credential helper protocol for ECR registries
+// The request is read from in and the response written to out
+// Credentials are derived from SSO role credentials, so store is a no-op
+func DockerCredentialHelper(ctx context.Context, o *OIDCClientAPI, s *Client, inputs DockerCredentialFlagInputs, in io.Reader, out io.Writer) error {
+	switch inputs.Action {
+	case "get":
+		serverURL, err := readServerURL(in)
+		if err != nil {
+			return err
+		}
+		creds, err := dockerCredentials(ctx, o, s, inputs, serverURL)
+		if err != nil {
+			return err
+		}
+		return json.NewEncoder(out).Encode(creds)
+	case "store":
+		// read and discard, ecr credentials are never stored
+		_, err := io.Copy(io.Discard, in)
+		return err
+	case "erase":
+		serverURL, err := readServerURL(in)
+		if err != nil {
+			return err
+		}
+		if accountID, region, ok := parseECRRegistry(serverURL); ok {
+			key := ecrCacheKey(inputs.StartURL, accountID, ecrRoleName(inputs, accountID), region)
+			if err := os.Remove(credentialsCacheDestination(key)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		return nil
+	case "list":
+		return json.NewEncoder(out).Encode(listECRRegistries(inputs))
+	default:
+		return fmt.Errorf("unknown docker credential helper action %q", inputs.Action)
+	}
+}
+
+// dockerCredentials returns ecr credentials for the registry, using the cached token while valid
+func dockerCredentials(ctx context.Context, o *OIDCClientAPI, s *Client, inputs DockerCredentialFlagInputs, serverURL string) (DockerCredentials, error) {
+	logger := zerolog.Ctx(ctx)
+
+	accountID, region, ok := parseECRRegistry(serverURL)
+	if !ok {
+		logger.Debug().Msgf("%s is not an ecr registry", serverURL)
+		return DockerCredentials{}, errDockerCredentialsNotFound
+	}
+	roleName := ecrRoleName(inputs, accountID)
+	if len(roleName) == 0 {
+		logger.Debug().Msgf("No ecr role configured for account %s", accountID)
+		return DockerCredentials{}, errDockerCredentialsNotFound
+	}
+
+	if err := checkStartURL(s.policy, inputs.StartURL); err != nil {
+		return DockerCredentials{}, err
+	}
+	if err := checkPolicy(ctx, s, "", accountID, roleName, "", file.OutputProcess); err != nil {
+		return DockerCredentials{}, err
+	}
+	key := ecrCacheKey(inputs.StartURL, accountID, roleName, region)
+	maxDuration := s.safeguardedDuration(ctx, accountID, roleName)
+	entry, ok := readECRCache(ctx, key, maxDuration)
+	if !ok {
+		roleCredentials, err := cachedRoleCredentials(ctx, o, s, accountID, roleName, inputs.StartURL)
+		if err != nil {
+			return DockerCredentials{}, err
+		}
+		entry, err = ecrAuthorization(ctx, region, roleCredentials)
+		if err != nil {
+			return DockerCredentials{}, err
+		}
+		entry.ExpiresAt = capExpiration(entry.ExpiresAt, maxDuration)
+		entry.CachedAt = time.Now()
+		writeSecretToFile(ctx, &entry, credentialsCacheDestination(key))
+	}
+
+	return DockerCredentials{
+		ServerURL: serverURL,
+		Username:  entry.Username,
+		Secret:    entry.Secret,
+	}, nil
+}
+
+// ecrAuthorization retrieves an ecr authorization token with the role credentials
+func ecrAuthorization(ctx context.Context, region string, creds *sso.GetRoleCredentialsOutput) (ecrCacheEntry, error) {
+	output, err := newECRClient(region, roleCredentialsProvider(creds)).GetAuthorizationToken(ctx, &ecr.GetAuthorizationTokenInput{})
+	if err != nil {
+		// pass through for debug
+		_ = GetAWSErrorCode(ctx, err)
+		return ecrCacheEntry{}, err
+	}
+	if len(output.AuthorizationData) == 0 || output.AuthorizationData[0].AuthorizationToken == nil {
+		return ecrCacheEntry{}, errors.New("GetAuthorizationToken returned no authorization data")
+	}
+
+	data := output.AuthorizationData[0]
+	decoded, err := base64.StdEncoding.DecodeString(*data.AuthorizationToken)
+	if err != nil {
+		return ecrCacheEntry{}, fmt.Errorf("unable to decode ecr authorization token: %w", err)
+	}
+	username, secret, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return ecrCacheEntry{}, errors.New("ecr authorization token is not in user:password form")
+	}
+	return ecrCacheEntry{
+		Username:  username,
+		Secret:    secret,
+		ExpiresAt: aws.ToTime(data.ExpiresAt),
+	}, nil
+}
+
+// readECRCache returns the cached ecr token when it is not about to expire
+// The expiration is capped to maxDuration after caching, like cached role credentials.
+func readECRCache(ctx context.Context, key string, maxDuration time.Duration) (ecrCacheEntry, bool) {
+	destination := credentialsCacheDestination(key)
+	if !exists(ctx, destination) {
+		return ecrCacheEntry{}, false
+	}
+	content, err := os.ReadFile(destination)
+	if err != nil {
+		return ecrCacheEntry{}, false
+	}
+	entry := ecrCacheEntry{}
+	if err := json.Unmarshal(content, &entry); err != nil {
+		return ecrCacheEntry{}, false
+	}
+	if maxDuration > 0 {
+		if capped := entry.CachedAt.Add(maxDuration); capped.Before(entry.ExpiresAt) {
+			entry.ExpiresAt = capped
+		}
+	}
+	if entry.ExpiresAt.Before(time.Now().Add(credentialsExpiryWindow)) {
+		return ecrCacheEntry{}, false
+	}
+	return entry, true
+}
+
+// listECRRegistries returns the registries of configured accounts in the region
+func listECRRegistries(inputs DockerCredentialFlagInputs) map[string]string {
+	registries := map[string]string{}
+	for accountID := range inputs.Roles {
+		registries[fmt.Sprintf("https://%s.dkr.ecr.%s.amazonaws.com", accountID, serviceRegion(inputs.Region))] = "AWS"
+	}
+	return registries
+}
+
+// parseECRRegistry returns the account id and region of an ecr registry server url
+func parseECRRegistry(serverURL string) (string, string, bool) {
+	host := strings.TrimPrefix(strings.TrimPrefix(serverURL, "https://"), "http://")
+	host, _, _ = strings.Cut(host, "/")
+	host, _, _ = strings.Cut(host, ":")
+	matches := ecrRegistryPattern.FindStringSubmatch(host)
+	if matches == nil {
+		return "", "", false
+	}
+	return matches[1], matches[3], true
+}
+
+// readServerURL reads the server url sent by docker on stdin
+func readServerURL(in io.Reader) (string, error) {
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	serverURL := strings.TrimSpace(line)
+	if len(serverURL) == 0 {
+		return "", errors.New("no server url provided")
+	}
+	return serverURL, nil
+}
+
+// ecrCacheKey returns the cache key for an ecr registry token fetched with the role
+func ecrCacheKey(startURL, accountID, roleName, region string) string {
+	return fmt.Sprintf("ecr-%s-%s-%s-%s", startURL, accountID, roleName, region)
+}
+
+// ecrRoleName returns the role configured for the account, or the default role
+func ecrRoleName(inputs DockerCredentialFlagInputs, accountID string) string {
+	if roleName, ok := inputs.Roles[accountID]; ok {
+		return roleName
+	}
+	return inputs.DefaultRole
+}
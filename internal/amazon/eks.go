@@ -0,0 +1,154 @@
+package amazon
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/rs/zerolog"
+
+	"ssoctx/internal/file"
+)
+
+const (
+	eksTokenPrefix       = "k8s-aws-v1."
+	eksClusterIDHeader   = "x-k8s-aws-id"
+	eksPresignExpires    = "60"
+	eksTokenLifetime     = 14 * time.Minute
+	execCredentialKind   = "ExecCredential"
+	execCredentialAPIVer = "client.authentication.k8s.io/v1beta1"
+)
+
+// EKSFlagInputs contains all needed inputs for EKS tokens and kubeconfig
+type EKSFlagInputs struct {
+	AccountID  string
+	RoleName   string
+	StartURL   string
+	Region     string
+	Cluster    string
+	Kubeconfig string // kubeconfig path to write the user entry to
+	Context    string // existing kubeconfig context to point at the user entry
+	UserName   string // overrides the kubeconfig user entry name
+}
+
+// ExecCredential is the response expected from a kubectl exec credential plugin
+type ExecCredential struct {
+	Kind       string               `json:"kind"`
+	APIVersion string               `json:"apiVersion"`
+	Spec       struct{}             `json:"spec"`
+	Status     ExecCredentialStatus `json:"status"`
+}
+
+// ExecCredentialStatus contains the token of an ExecCredential
+type ExecCredentialStatus struct {
+	ExpirationTimestamp string `json:"expirationTimestamp"`
+	Token               string `json:"token"`
+}
+
+// EKSToken is used to output an ExecCredential for an EKS cluster
+// Role credentials are cached so tokens are presigned offline until the credentials expire
+func EKSToken(ctx context.Context, o *OIDCClientAPI, s *Client, inputs EKSFlagInputs) {
+	logger := zerolog.Ctx(ctx)
+	enforceStartURL(ctx, s, inputs.StartURL)
+	enforcePolicy(ctx, s, "", inputs.AccountID, inputs.RoleName, "", file.OutputProcess)
+
+	roleCredentials, err := cachedRoleCredentials(ctx, o, s, inputs.AccountID, inputs.RoleName, inputs.StartURL)
+	if err != nil {
+		logger.Fatal().Msgf("Encountered error attempting to getRoleCredentials: %v", err)
+	}
+
+	token, expiration, err := eksToken(ctx, inputs.Region, inputs.Cluster, roleCredentials)
+	if err != nil {
+		logger.Fatal().Msgf("Encountered error creating eks token: %v", err)
+	}
+
+	credential := ExecCredential{
+		Kind:       execCredentialKind,
+		APIVersion: execCredentialAPIVer,
+		Status: ExecCredentialStatus{
+			ExpirationTimestamp: expiration.UTC().Format(time.RFC3339),
+			Token:               token,
+		},
+	}
+	bytes, _ := json.Marshal(credential)
+	os.Stdout.Write(bytes)
+}
+
+// EKSKubeconfig is used to write a kubeconfig user entry that execs ssoctx for tokens
+func EKSKubeconfig(ctx context.Context, o *OIDCClientAPI, s *Client, inputs EKSFlagInputs) {
+	logger := zerolog.Ctx(ctx)
+
+	if len(inputs.AccountID) == 0 || len(inputs.RoleName) == 0 {
+		destination := clientInfoFileDestination(inputs.StartURL)
+		clientInformation, err := o.processClientInformation(ctx, destination)
+		if err != nil {
+			logger.Fatal().Msgf("Encountered error in processClientInformation: %v", err)
+		}
+		writeSecretToFile(ctx, &clientInformation, destination)
+		inputs.AccountID, inputs.RoleName = selectAccountAndRole(ctx, s, clientInformation.AccessToken, inputs.AccountID, inputs.RoleName)
+	}
+
+	user := eksKubeconfigUser(inputs)
+	if err := file.WriteKubeconfigUser(ctx, inputs.Kubeconfig, user, inputs.Context); err != nil {
+		logger.Fatal().Msgf("Encountered error writing kubeconfig: %v", err)
+	}
+	logger.Info().Msgf("Wrote kubeconfig user %s for cluster %s", user.Name, inputs.Cluster)
+}
+
+// eksKubeconfigUser returns the kubeconfig user entry that execs the eks token command
+func eksKubeconfigUser(inputs EKSFlagInputs) file.KubeconfigUser {
+	name := inputs.UserName
+	if len(name) == 0 {
+		name = fmt.Sprintf("%s-%s-%s-%s", ProjectFileName, inputs.Cluster, inputs.AccountID, inputs.RoleName)
+	}
+	args := []string{
+		"eks", "token",
+		"--cluster", inputs.Cluster,
+		"-a", inputs.AccountID,
+		"-n", inputs.RoleName,
+	}
+	if len(inputs.StartURL) > 0 {
+		args = append(args, "-u", inputs.StartURL)
+	}
+	if len(inputs.Region) > 0 {
+		args = append(args, "-r", inputs.Region)
+	}
+	return file.KubeconfigUser{
+		Name:       name,
+		APIVersion: execCredentialAPIVer,
+		Command:    ProjectFileName,
+		Args:       args,
+	}
+}
+
+// eksToken presigns an sts GetCallerIdentity request for the cluster
+// The presign happens locally, so no network is needed once role credentials are known
+func eksToken(ctx context.Context, region, cluster string, creds *sso.GetRoleCredentialsOutput) (string, time.Time, error) {
+	client := sts.NewPresignClient(sts.New(sts.Options{
+		Region:      serviceRegion(region),
+		Credentials: roleCredentialsProvider(creds),
+	}))
+	presigned, err := client.PresignGetCallerIdentity(ctx, &sts.GetCallerIdentityInput{}, func(po *sts.PresignOptions) {
+		po.ClientOptions = append(po.ClientOptions, func(o *sts.Options) {
+			o.APIOptions = append(o.APIOptions,
+				smithyhttp.AddHeaderValue(eksClusterIDHeader, cluster),
+				smithyhttp.AddHeaderValue("X-Amz-Expires", eksPresignExpires),
+			)
+		})
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiration := time.Now().Add(eksTokenLifetime)
+	if credsExpiration := time.UnixMilli(creds.RoleCredentials.Expiration); credsExpiration.Before(expiration) {
+		expiration = credsExpiration
+	}
+	return eksTokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(presigned.URL)), expiration, nil
+}
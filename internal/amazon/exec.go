@@ -0,0 +1,198 @@
+package amazon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/rs/zerolog"
+
+	"ssoctx/internal/file"
+)
+
+var (
+	newSSMClient = func(region string, creds aws.CredentialsProvider) SSMClient {
+		return ssm.New(ssm.Options{Region: serviceRegion(region), Credentials: creds})
+	}
+	newSecretsManagerClient = func(region string, creds aws.CredentialsProvider) SecretsManagerClient {
+		return secretsmanager.New(secretsmanager.Options{Region: serviceRegion(region), Credentials: creds})
+	}
+
+	invalidEnvChars = regexp.MustCompile(`[^A-Z0-9_]`)
+
+	// reservedEnvNames are set from the role credentials and cannot be exported from parameters or secrets
+	reservedEnvNames = []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_PROFILE"}
+)
+
+// SSMClient is used to abstract the client calls for mock testing
+type SSMClient interface {
+	ssm.GetParametersByPathAPIClient
+}
+
+// SecretsManagerClient is used to abstract the client calls for mock testing
+type SecretsManagerClient interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// ExecFlagInputs contains all needed inputs for Exec
+type ExecFlagInputs struct {
+	AccountID string
+	RoleName  string
+	StartURL  string
+	Region    string
+	SSMPaths  []string          // parameter paths exported to the child
+	Secrets   []string          // secret names or arns exported to the child
+	EnvMap    map[string]string // env var name per parameter or secret name, overrides the derived name
+	Command   []string
+}
+
+// Exec is used to run a command with role credentials, SSM parameters and secrets in its environment
+// Nothing is exported to the calling shell, only the child process gets the values
+func Exec(ctx context.Context, o *OIDCClientAPI, s *Client, inputs ExecFlagInputs) {
+	logger := zerolog.Ctx(ctx)
+
+	_, _, roleCredentials := resolveRoleCredentials(ctx, o, s, inputs.AccountID, inputs.RoleName, inputs.StartURL, file.OutputExec)
+	provider := roleCredentialsProvider(roleCredentials)
+
+	values := map[string]string{}
+	if len(inputs.SSMPaths) > 0 {
+		if err := ssmEnvironment(ctx, newSSMClient(inputs.Region, provider), inputs.SSMPaths, inputs.EnvMap, values); err != nil {
+			logger.Fatal().Msgf("Encountered error reading ssm parameters: %v", err)
+		}
+	}
+	if len(inputs.Secrets) > 0 {
+		if err := secretsEnvironment(ctx, newSecretsManagerClient(inputs.Region, provider), inputs.Secrets, inputs.EnvMap, values); err != nil {
+			logger.Fatal().Msgf("Encountered error reading secrets: %v", err)
+		}
+	}
+
+	if err := checkReservedEnv(values); err != nil {
+		logger.Fatal().Msgf("%v", err)
+	}
+
+	cmd := execCmd(inputs.Command[0], inputs.Command[1:]...)
+	cmd.Env = childEnvironment(os.Environ(), roleCredentials, inputs.Region, values)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
+		logger.Fatal().Msgf("Encountered error running %s: %v", inputs.Command[0], err)
+	}
+}
+
+// ssmEnvironment adds the parameters under the paths to values
+// Names are derived from the parameter name relative to its path, so /app/dev/db/password is DB_PASSWORD
+func ssmEnvironment(ctx context.Context, client SSMClient, paths []string, envMap map[string]string, values map[string]string) error {
+	logger := zerolog.Ctx(ctx)
+	for _, parameterPath := range paths {
+		paginator := ssm.NewGetParametersByPathPaginator(client, &ssm.GetParametersByPathInput{
+			Path:           aws.String(parameterPath),
+			Recursive:      aws.Bool(true),
+			WithDecryption: aws.Bool(true),
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				// pass through for debug
+				_ = GetAWSErrorCode(ctx, err)
+				return err
+			}
+			for _, parameter := range page.Parameters {
+				name := aws.ToString(parameter.Name)
+				key := envName(envMap, name, strings.TrimPrefix(name, parameterPath))
+				logger.Debug().Msgf("Exporting parameter %s as %s", name, key)
+				values[key] = aws.ToString(parameter.Value)
+			}
+		}
+	}
+	return nil
+}
+
+// secretsEnvironment adds the secret string of each secret to values
+// Names are derived from the last part of the secret name, so app/dev/api-key is API_KEY
+func secretsEnvironment(ctx context.Context, client SecretsManagerClient, secrets []string, envMap map[string]string, values map[string]string) error {
+	logger := zerolog.Ctx(ctx)
+	for _, secret := range secrets {
+		output, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(secret)})
+		if err != nil {
+			// pass through for debug
+			_ = GetAWSErrorCode(ctx, err)
+			return err
+		}
+		if output.SecretString == nil {
+			return fmt.Errorf("secret %s has no string value", secret)
+		}
+		key := envName(envMap, secret, path.Base(aws.ToString(output.Name)))
+		logger.Debug().Msgf("Exporting secret %s as %s", secret, key)
+		values[key] = aws.ToString(output.SecretString)
+	}
+	return nil
+}
+
+// envName returns the mapped env var name for name or the name derived from relative
+func envName(envMap map[string]string, name, relative string) string {
+	if mapped, ok := envMap[name]; ok {
+		return mapped
+	}
+	return invalidEnvChars.ReplaceAllString(strings.ToUpper(strings.Trim(relative, "/")), "_")
+}
+
+// checkReservedEnv returns an error when a parameter or secret is exported as a reserved name
+// Otherwise the value would silently replace the role credentials of the child.
+func checkReservedEnv(values map[string]string) error {
+	for _, name := range reservedEnvNames {
+		if _, ok := values[name]; ok {
+			return fmt.Errorf("a parameter or secret is exported as %s, which is set from the role credentials, map it to another name with --env-map", name)
+		}
+	}
+	return nil
+}
+
+// childEnvironment returns the environment of the child process
+// Role credentials replace any aws credentials of the parent and values are added sorted by name
+func childEnvironment(parent []string, creds *sso.GetRoleCredentialsOutput, region string, values map[string]string) []string {
+	overrides := map[string]string{
+		"AWS_ACCESS_KEY_ID":     aws.ToString(creds.RoleCredentials.AccessKeyId),
+		"AWS_SECRET_ACCESS_KEY": aws.ToString(creds.RoleCredentials.SecretAccessKey),
+		"AWS_SESSION_TOKEN":     aws.ToString(creds.RoleCredentials.SessionToken),
+	}
+	if len(region) > 0 {
+		overrides["AWS_REGION"] = region
+		overrides["AWS_DEFAULT_REGION"] = region
+	}
+	for key, value := range values {
+		overrides[key] = value
+	}
+
+	env := make([]string, 0, len(parent)+len(overrides))
+	for _, entry := range parent {
+		key, _, _ := strings.Cut(entry, "=")
+		if _, ok := overrides[key]; ok || key == "AWS_PROFILE" {
+			continue
+		}
+		env = append(env, entry)
+	}
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		env = append(env, key+"="+overrides[key])
+	}
+	return env
+}
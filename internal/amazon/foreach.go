@@ -0,0 +1,219 @@
+package amazon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/rs/zerolog"
+
+	"ssoctx/internal/file"
+)
+
+// defaultForeachParallel is the number of accounts a command runs in at once
+const defaultForeachParallel = 8
+
+// ForeachFlagInputs contains all needed inputs for Foreach
+type ForeachFlagInputs struct {
+	RoleName string
+	StartURL string
+	Region   string
+	Accounts string // glob pattern matched against account ids and names
+	All      bool
+	Parallel int
+	Confirm  string // account name, id or alias typed ahead for safeguarded accounts
+	Command  []string
+}
+
+// foreachResult is the outcome of running the command in an account
+type foreachResult struct {
+	Account  types.AccountInfo
+	ExitCode int
+	Err      error
+}
+
+// Foreach is used to run a command once per account with the role credentials in its environment
+// Output lines are prefixed with the account and a summary of exit codes is printed at the end
+// Returns false when the command failed in any account
+func Foreach(ctx context.Context, o *OIDCClientAPI, s *Client, inputs ForeachFlagInputs) bool {
+	logger := zerolog.Ctx(ctx)
+	enforceStartURL(ctx, s, inputs.StartURL)
+
+	destination := clientInfoFileDestination(inputs.StartURL)
+	clientInformation, err := o.processClientInformation(ctx, destination)
+	if err != nil {
+		logger.Fatal().Msgf("Encountered error in processClientInformation: %v", err)
+	}
+	writeSecretToFile(ctx, &clientInformation, destination)
+
+	accountsOutput, err := s.listAccounts(ctx, clientInformation.AccessToken)
+	if err != nil {
+		logger.Fatal().Msgf("Encountered error in listAccounts: %v", err)
+	}
+	accounts, err := matchAccounts(accountsOutput.AccountList, inputs.Accounts, inputs.All)
+	if err != nil {
+		logger.Fatal().Msgf("%v", err)
+	}
+	if len(accounts) == 0 {
+		logger.Fatal().Msgf("No accounts match %q", inputs.Accounts)
+	}
+	// every account is checked and safeguarded accounts are confirmed one by one before any command runs
+	for _, account := range accounts {
+		enforcePolicy(ctx, s, clientInformation.AccessToken, aws.ToString(account.AccountId), inputs.RoleName, "", file.OutputExec)
+		guardSelection(ctx, s, clientInformation.AccessToken, aws.ToString(account.AccountId), inputs.RoleName, "", false, inputs.Confirm)
+	}
+	logger.Debug().Msgf("Running %s in %d accounts as %s", inputs.Command[0], len(accounts), inputs.RoleName)
+
+	out := &lockedWriter{w: os.Stdout}
+	results := runForeach(accounts, inputs.Parallel, func(account types.AccountInfo) (int, error) {
+		return runInAccount(ctx, s, clientInformation.AccessToken, account, inputs, out)
+	})
+	return printForeachSummary(os.Stdout, results)
+}
+
+// matchAccounts returns the accounts whose id or name matches the pattern, or every account with all
+func matchAccounts(accounts []types.AccountInfo, pattern string, all bool) ([]types.AccountInfo, error) {
+	if all {
+		return accounts, nil
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid accounts pattern %q: %w", pattern, err)
+	}
+	var matched []types.AccountInfo
+	for _, account := range accounts {
+		idMatch, _ := path.Match(pattern, aws.ToString(account.AccountId))
+		nameMatch, _ := path.Match(pattern, aws.ToString(account.AccountName))
+		if idMatch || nameMatch {
+			matched = append(matched, account)
+		}
+	}
+	return matched, nil
+}
+
+// runForeach runs fn for every account with at most parallel running at once
+// Results are returned in the order of accounts
+func runForeach(accounts []types.AccountInfo, parallel int, fn func(types.AccountInfo) (int, error)) []foreachResult {
+	if parallel < 1 {
+		parallel = defaultForeachParallel
+	}
+	results := make([]foreachResult, len(accounts))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < parallel && w < len(accounts); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				code, err := fn(accounts[i])
+				results[i] = foreachResult{Account: accounts[i], ExitCode: code, Err: err}
+			}
+		}()
+	}
+	for i := range accounts {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// runInAccount runs the command with role credentials for the account and returns its exit code
+func runInAccount(ctx context.Context, s *Client, accessToken string, account types.AccountInfo, inputs ForeachFlagInputs, out io.Writer) (int, error) {
+	accountID := aws.ToString(account.AccountId)
+	roleCredentials, err := s.getRolesCredentials(ctx, accountID, inputs.RoleName, accessToken)
+	if err != nil {
+		return -1, fmt.Errorf("unable to get %s credentials: %w", inputs.RoleName, err)
+	}
+
+	prefix := fmt.Sprintf("[%s %s] ", accountID, aws.ToString(account.AccountName))
+	stdout := &prefixWriter{prefix: prefix, w: out}
+	stderr := &prefixWriter{prefix: prefix, w: out}
+	defer stdout.Flush()
+	defer stderr.Flush()
+
+	cmd := execCmd(inputs.Command[0], inputs.Command[1:]...)
+	cmd.Env = childEnvironment(os.Environ(), roleCredentials, inputs.Region, map[string]string{
+		"AWS_ACCOUNT_ID":   accountID,
+		"AWS_ACCOUNT_NAME": aws.ToString(account.AccountName),
+	})
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), nil
+		}
+		return -1, err
+	}
+	return 0, nil
+}
+
+// printForeachSummary prints the exit code per account and returns true when every command succeeded
+func printForeachSummary(w io.Writer, results []foreachResult) bool {
+	succeeded := 0
+	fmt.Fprintln(w)
+	for _, result := range results {
+		status := fmt.Sprintf("exit %d", result.ExitCode)
+		if result.Err != nil {
+			status = fmt.Sprintf("error: %v", result.Err)
+		}
+		if result.Err == nil && result.ExitCode == 0 {
+			succeeded++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", aws.ToString(result.Account.AccountId), aws.ToString(result.Account.AccountName), status)
+	}
+	fmt.Fprintf(w, "%d of %d accounts succeeded\n", succeeded, len(results))
+	return succeeded == len(results)
+}
+
+// lockedWriter serializes writes from concurrent commands
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// prefixWriter writes complete lines with a prefix so output of concurrent commands does not interleave
+type prefixWriter struct {
+	prefix string
+	w      io.Writer
+	buf    bytes.Buffer
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf.Write(b)
+	for {
+		line, err := p.buf.ReadString('\n')
+		if err != nil {
+			// keep the partial line for the next write
+			p.buf.Reset()
+			p.buf.WriteString(line)
+			return len(b), nil
+		}
+		if _, err := io.WriteString(p.w, p.prefix+line); err != nil {
+			return len(b), err
+		}
+	}
+}
+
+// Flush writes any remaining partial line
+func (p *prefixWriter) Flush() {
+	if p.buf.Len() > 0 {
+		_, _ = io.WriteString(p.w, p.prefix+strings.TrimRight(p.buf.String(), "\n")+"\n")
+		p.buf.Reset()
+	}
+}
@@ -0,0 +1,209 @@
+package amazon
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/rs/zerolog"
+
+	"ssoctx/internal/file"
+)
+
+const (
+	// maxHistoryEntries is how many selections are kept in the history
+	maxHistoryEntries = 50
+	// recentAccounts is how many recently used accounts are pinned in the account picker
+	recentAccounts = 5
+)
+
+// selectionHistory is the account and role selections of a start url, most recent first
+type selectionHistory struct {
+	Entries []historyEntry
+}
+
+// historyEntry is a selected account and role
+type historyEntry struct {
+	AccountID   string
+	AccountName string
+	RoleName    string
+	UsedAt      time.Time
+}
+
+// history is the state of the selection history used by a Client
+type history struct {
+	startURL   string
+	favourites []file.Favourite
+	mu         sync.Mutex // serializes read-modify-write of the history file
+}
+
+// UseHistory enables the selection history of the start url and the favourites pinned in config
+func (c *Client) UseHistory(startURL string, favourites []file.Favourite) {
+	if len(startURL) == 0 {
+		return
+	}
+	c.history = &history{startURL: startURL, favourites: favourites}
+}
+
+// recordSelection moves the account and role to the top of the history
+func (c *Client) recordSelection(ctx context.Context, accountID, roleName string) {
+	if c.history == nil {
+		return
+	}
+	c.history.mu.Lock()
+	defer c.history.mu.Unlock()
+
+	entry := historyEntry{AccountID: accountID, RoleName: roleName, UsedAt: time.Now()}
+	if cached, _ := c.catalogAccounts(ctx); len(cached) > 0 {
+		if account, ok := findAccount(cached, accountID); ok {
+			entry.AccountName = aws.ToString(account.AccountName)
+		}
+	}
+
+	h := c.readHistory(ctx)
+	if len(entry.AccountName) == 0 {
+		entry.AccountName = historyAccountName(h.Entries, accountID)
+	}
+	entries := []historyEntry{entry}
+	for _, e := range h.Entries {
+		if e.AccountID != accountID || e.RoleName != roleName {
+			entries = append(entries, e)
+		}
+	}
+	h.Entries = entries[:min(len(entries), maxHistoryEntries)]
+	writeStructToFile(ctx, &h, credentialsCacheDestination(historyCacheKey(c.history.startURL)))
+}
+
+// previousSelection returns the selection before the current one, like kubectx -
+func (c *Client) previousSelection(ctx context.Context) (historyEntry, bool) {
+	if c.history == nil {
+		return historyEntry{}, false
+	}
+	h := c.readHistory(ctx)
+	if len(h.Entries) < 2 {
+		return historyEntry{}, false
+	}
+	return h.Entries[1], true
+}
+
+// pinnedAccounts returns the favourite accounts followed by the recently used accounts
+// Names are taken from the known accounts, then the history, so pinned options match the listed ones
+func (c *Client) pinnedAccounts(ctx context.Context, known []types.AccountInfo) []types.AccountInfo {
+	if c.history == nil {
+		return nil
+	}
+	entries := c.readHistory(ctx).Entries
+
+	var pinned []types.AccountInfo
+	seen := map[string]bool{}
+	add := func(accountID, accountName string) {
+		if seen[accountID] {
+			return
+		}
+		seen[accountID] = true
+		if account, ok := findAccount(known, accountID); ok {
+			pinned = append(pinned, account)
+			return
+		}
+		if len(accountName) == 0 {
+			accountName = accountID
+		}
+		pinned = append(pinned, types.AccountInfo{AccountId: aws.String(accountID), AccountName: aws.String(accountName)})
+	}
+
+	for _, favourite := range c.history.favourites {
+		add(favourite.AccountID, historyAccountName(entries, favourite.AccountID))
+	}
+	recent := 0
+	for _, e := range entries {
+		if recent == recentAccounts {
+			break
+		}
+		if !seen[e.AccountID] {
+			recent++
+		}
+		add(e.AccountID, e.AccountName)
+	}
+	return pinned
+}
+
+// preferredRoles returns the role last used for the account followed by its favourite roles
+func (c *Client) preferredRoles(ctx context.Context, accountID string) []string {
+	if c.history == nil {
+		return nil
+	}
+	var roles []string
+	for _, e := range c.readHistory(ctx).Entries {
+		if e.AccountID == accountID {
+			roles = append(roles, e.RoleName)
+			break
+		}
+	}
+	for _, favourite := range c.history.favourites {
+		if favourite.AccountID == accountID && len(favourite.RoleName) > 0 {
+			roles = append(roles, favourite.RoleName)
+		}
+	}
+	return roles
+}
+
+// preferredAccountRoles returns the pair ids of the favourites followed by the recent selections
+// A favourite without a role prefers the account while its roles are not cached.
+func (c *Client) preferredAccountRoles(ctx context.Context) []string {
+	if c.history == nil {
+		return nil
+	}
+	var ids []string
+	for _, favourite := range c.history.favourites {
+		ids = append(ids, favourite.AccountID+"/"+favourite.RoleName)
+	}
+	for _, e := range c.readHistory(ctx).Entries {
+		ids = append(ids, e.AccountID+"/"+e.RoleName)
+	}
+	return ids
+}
+
+// readHistory returns the selection history or an empty history
+func (c *Client) readHistory(ctx context.Context) selectionHistory {
+	logger := zerolog.Ctx(ctx)
+	var h selectionHistory
+
+	content, err := os.ReadFile(credentialsCacheDestination(historyCacheKey(c.history.startURL)))
+	if err != nil {
+		return h
+	}
+	if err := json.Unmarshal(content, &h); err != nil {
+		logger.Debug().Msgf("Ignoring unreadable history: %v", err)
+		return selectionHistory{}
+	}
+	return h
+}
+
+// historyAccountName returns the last known name of the account in the history
+func historyAccountName(entries []historyEntry, accountID string) string {
+	for _, e := range entries {
+		if e.AccountID == accountID && len(e.AccountName) > 0 {
+			return e.AccountName
+		}
+	}
+	return ""
+}
+
+// findAccount returns the account with the id
+func findAccount(accounts []types.AccountInfo, accountID string) (types.AccountInfo, bool) {
+	for _, account := range accounts {
+		if aws.ToString(account.AccountId) == accountID {
+			return account, true
+		}
+	}
+	return types.AccountInfo{}, false
+}
+
+// historyCacheKey returns the cache key of the selection history for a start url
+func historyCacheKey(startURL string) string {
+	return "history-" + startURL
+}
@@ -0,0 +1,98 @@
+package amazon
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"ssoctx/internal/file"
+)
+
+func TestSelectionHistory(t *testing.T) {
+	tests := []struct {
+		name         string
+		selections   [][2]string // account id and role name, oldest first
+		favourites   []file.Favourite
+		wantPrevious string
+		wantPinned   []string
+		wantRoles    []string // preferred roles of 111111111111
+	}{
+		{
+			name:       "empty",
+			favourites: []file.Favourite{{AccountID: "333333333333"}},
+			wantPinned: []string{"prod-data 333333333333"},
+		},
+		{
+			name:         "recent first",
+			selections:   [][2]string{{"111111111111", "Admin"}, {"222222222222", "Developer"}, {"111111111111", "ReadOnly"}},
+			wantPrevious: "222222222222 Developer",
+			wantPinned:   []string{"prod-app 111111111111", "dev-app 222222222222"},
+			wantRoles:    []string{"ReadOnly"},
+		},
+		{
+			name:         "repeated selection moves to top",
+			selections:   [][2]string{{"111111111111", "Admin"}, {"222222222222", "Developer"}, {"111111111111", "Admin"}},
+			wantPrevious: "222222222222 Developer",
+			wantPinned:   []string{"prod-app 111111111111", "dev-app 222222222222"},
+			wantRoles:    []string{"Admin"},
+		},
+		{
+			name:         "favourites before recent",
+			selections:   [][2]string{{"222222222222", "Developer"}, {"111111111111", "Admin"}},
+			favourites:   []file.Favourite{{AccountID: "333333333333"}, {AccountID: "111111111111", RoleName: "ReadOnly"}},
+			wantPrevious: "222222222222 Developer",
+			wantPinned:   []string{"prod-data 333333333333", "prod-app 111111111111", "dev-app 222222222222"},
+			wantRoles:    []string{"Admin", "ReadOnly"},
+		},
+		{
+			name:       "unknown favourite",
+			favourites: []file.Favourite{{AccountID: "444444444444"}},
+			wantPinned: []string{"444444444444 444444444444"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempCredentialsCache(t)
+			calls := 0
+			c := catalogClient(testAccounts(), false, &calls)
+			c.saveCatalogAccounts(zerologTestingContext, testAccounts())
+			c.UseHistory(testCatalogURL, tt.favourites)
+
+			for _, selection := range tt.selections {
+				c.recordSelection(zerologTestingContext, selection[0], selection[1])
+			}
+
+			previous, ok := c.previousSelection(zerologTestingContext)
+			if got := previous.AccountID + " " + previous.RoleName; ok != (len(tt.wantPrevious) > 0) || (ok && got != tt.wantPrevious) {
+				t.Errorf("previousSelection() = %q, %v, want %q", got, ok, tt.wantPrevious)
+			}
+
+			var pinned []string
+			for _, account := range c.pinnedAccounts(zerologTestingContext, testAccounts()) {
+				pinned = append(pinned, aws.ToString(account.AccountName)+" "+aws.ToString(account.AccountId))
+			}
+			if !reflect.DeepEqual(pinned, tt.wantPinned) {
+				t.Errorf("pinnedAccounts() = %v, want %v", pinned, tt.wantPinned)
+			}
+
+			if roles := c.preferredRoles(zerologTestingContext, "111111111111"); !reflect.DeepEqual(roles, tt.wantRoles) {
+				t.Errorf("preferredRoles() = %v, want %v", roles, tt.wantRoles)
+			}
+		})
+	}
+}
+
+func TestSelectionHistoryLimit(t *testing.T) {
+	useTempCredentialsCache(t)
+	c := NewSSOClient(&mockSSOClient{})
+	c.UseHistory(testCatalogURL, nil)
+
+	for i := 0; i < maxHistoryEntries+10; i++ {
+		c.recordSelection(zerologTestingContext, "111111111111", time.Duration(i).String())
+	}
+	if entries := c.readHistory(zerologTestingContext).Entries; len(entries) != maxHistoryEntries {
+		t.Errorf("history has %d entries, want %d", len(entries), maxHistoryEntries)
+	}
+}
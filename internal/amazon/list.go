@@ -0,0 +1,239 @@
+package amazon
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v2"
+
+	"ssoctx/internal/file"
+)
+
+const (
+	listRolesWorkers = 8
+	listRolesRate    = 10 // ListAccountRoles calls per second
+)
+
+// ListFlagInputs contains all needed inputs for List
+type ListFlagInputs struct {
+	StartURL   string
+	Accounts   string // glob pattern matched against account ids and names
+	Roles      bool   // list the roles of every account
+	RoleFilter string // glob pattern matched against role names
+	Output     string // table, json, csv or yaml
+}
+
+// AccountEntry is an account and its roles as output by List
+type AccountEntry struct {
+	AccountID    string   `json:"account_id" yaml:"account_id"`
+	AccountName  string   `json:"account_name" yaml:"account_name"`
+	EmailAddress string   `json:"email_address" yaml:"email_address"`
+	Environment  string   `json:"environment,omitempty" yaml:"environment,omitempty"`
+	Team         string   `json:"team,omitempty" yaml:"team,omitempty"`
+	Aliases      []string `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+	Roles        []string `json:"roles,omitempty" yaml:"roles,omitempty"`
+}
+
+// List is used to output the accounts and roles available without prompting
+func List(ctx context.Context, o *OIDCClientAPI, s *Client, inputs ListFlagInputs) {
+	logger := zerolog.Ctx(ctx)
+
+	destination := clientInfoFileDestination(inputs.StartURL)
+	clientInformation, err := o.processClientInformation(ctx, destination)
+	if err != nil {
+		logger.Fatal().Msgf("Encountered error in processClientInformation: %v", err)
+	}
+	writeSecretToFile(ctx, &clientInformation, destination)
+
+	accountsOutput, err := s.listAccounts(ctx, clientInformation.AccessToken)
+	if err != nil {
+		logger.Fatal().Msgf("Encountered error in listAccounts: %v", err)
+	}
+	accounts, err := matchAccounts(accountsOutput.AccountList, inputs.Accounts, len(inputs.Accounts) == 0)
+	if err != nil {
+		logger.Fatal().Msgf("%v", err)
+	}
+
+	withRoles := inputs.Roles || len(inputs.RoleFilter) > 0
+	entries := accountEntries(accounts, s.labels.Metadata)
+	if withRoles {
+		if err := listEntryRoles(ctx, s, clientInformation.AccessToken, entries, inputs.RoleFilter); err != nil {
+			logger.Fatal().Msgf("Encountered error in listAvailableRoles: %v", err)
+		}
+	}
+
+	if err := writeAccountEntries(os.Stdout, entries, inputs.Output, withRoles); err != nil {
+		logger.Fatal().Msgf("%v", err)
+	}
+}
+
+// accountEntries converts the account list to entries described by the account metadata
+func accountEntries(accounts []types.AccountInfo, metadata map[string]file.AccountMetadata) []AccountEntry {
+	entries := make([]AccountEntry, len(accounts))
+	for i, account := range accounts {
+		id := aws.ToString(account.AccountId)
+		entries[i] = AccountEntry{
+			AccountID:    id,
+			AccountName:  aws.ToString(account.AccountName),
+			EmailAddress: aws.ToString(account.EmailAddress),
+			Environment:  metadata[id].Environment,
+			Team:         metadata[id].Team,
+			Aliases:      metadata[id].Aliases,
+		}
+	}
+	return entries
+}
+
+// listEntryRoles sets the roles matching the filter on every entry
+// Accounts are listed concurrently with the calls limited to listRolesRate per second
+func listEntryRoles(ctx context.Context, s *Client, accessToken string, entries []AccountEntry, roleFilter string) error {
+	if _, err := path.Match(roleFilter, ""); err != nil {
+		return fmt.Errorf("invalid role pattern %q: %w", roleFilter, err)
+	}
+
+	limiter := time.NewTicker(time.Second / listRolesRate)
+	defer limiter.Stop()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		jobs     = make(chan int)
+	)
+	for w := 0; w < listRolesWorkers && w < len(entries); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				<-limiter.C
+				roles, err := s.catalogRoles(ctx, entries[i].AccountID, accessToken)
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = fmt.Errorf("account %s: %w", entries[i].AccountID, err)
+					}
+					mu.Unlock()
+					continue
+				}
+				for _, role := range roles.RoleList {
+					name := aws.ToString(role.RoleName)
+					if matched, _ := path.Match(roleFilter, name); len(roleFilter) == 0 || matched {
+						entries[i].Roles = append(entries[i].Roles, name)
+					}
+				}
+			}
+		}()
+	}
+	for i := range entries {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return firstErr
+}
+
+// writeAccountEntries writes the entries in the output format
+// table and csv have a row per role when roles are listed,
+// and environment and team columns when any account has them
+func writeAccountEntries(w io.Writer, entries []AccountEntry, output string, withRoles bool) error {
+	described := hasAccountMetadata(entries)
+	switch output {
+	case "", "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		header := "ACCOUNT ID\tACCOUNT NAME\tEMAIL"
+		if withRoles {
+			header = "ACCOUNT ID\tACCOUNT NAME\tROLE"
+		}
+		if described {
+			header += "\tENVIRONMENT\tTEAM"
+		}
+		fmt.Fprintln(tw, header)
+		for _, row := range accountRows(entries, withRoles) {
+			line := fmt.Sprintf("%s\t%s\t%s", row[0], row[1], row[2])
+			if withRoles {
+				line = fmt.Sprintf("%s\t%s\t%s", row[0], row[1], row[3])
+			}
+			if described {
+				line += fmt.Sprintf("\t%s\t%s", row[4], row[5])
+			}
+			fmt.Fprintln(tw, line)
+		}
+		return tw.Flush()
+	case "csv":
+		cw := csv.NewWriter(w)
+		header := []string{"account_id", "account_name", "email_address"}
+		if withRoles {
+			header = append(header, "role_name")
+		}
+		if described {
+			header = append(header, "environment", "team")
+		}
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+		for _, row := range accountRows(entries, withRoles) {
+			record := append([]string{}, row[:3]...)
+			if withRoles {
+				record = append(record, row[3])
+			}
+			if described {
+				record = append(record, row[4], row[5])
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	case "yaml":
+		out, err := yaml.Marshal(entries)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(out)
+		return err
+	default:
+		return fmt.Errorf("unknown output %q, expected table, json, csv or yaml", output)
+	}
+}
+
+// accountRows returns the account id, name, email, role, environment and team of every row
+// Accounts without roles are skipped when roles are listed
+func accountRows(entries []AccountEntry, withRoles bool) [][]string {
+	var rows [][]string
+	for _, entry := range entries {
+		if !withRoles {
+			rows = append(rows, []string{entry.AccountID, entry.AccountName, entry.EmailAddress, "", entry.Environment, entry.Team})
+			continue
+		}
+		for _, role := range entry.Roles {
+			rows = append(rows, []string{entry.AccountID, entry.AccountName, entry.EmailAddress, role, entry.Environment, entry.Team})
+		}
+	}
+	return rows
+}
+
+// hasAccountMetadata returns true when any entry has an environment or team
+func hasAccountMetadata(entries []AccountEntry) bool {
+	for _, entry := range entries {
+		if len(entry.Environment) > 0 || len(entry.Team) > 0 {
+			return true
+		}
+	}
+	return false
+}
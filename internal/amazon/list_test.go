@@ -0,0 +1,146 @@
+package amazon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+
+	"ssoctx/internal/file"
+)
+
+func TestListEntryRoles(t *testing.T) {
+	s := &Client{client: &mockSSOClient{
+		ListAccountRolesAPI: func(ctx context.Context, params *sso.ListAccountRolesInput, optFns ...func(*sso.Options)) (*sso.ListAccountRolesOutput, error) {
+			if *params.AccountId == "333333333333" {
+				return nil, errors.New("throttled")
+			}
+			return &sso.ListAccountRolesOutput{RoleList: []types.RoleInfo{
+				{AccountId: params.AccountId, RoleName: aws.String("ReadOnly")},
+				{AccountId: params.AccountId, RoleName: aws.String("AdministratorAccess")},
+			}}, nil
+		},
+	}}
+
+	tests := []struct {
+		name       string
+		accounts   []types.AccountInfo
+		roleFilter string
+		want       [][]string
+		wantErr    bool
+	}{
+		{"all roles", testAccounts()[:2], "", [][]string{{"ReadOnly", "AdministratorAccess"}, {"ReadOnly", "AdministratorAccess"}}, false},
+		{"filtered roles", testAccounts()[:2], "Read*", [][]string{{"ReadOnly"}, {"ReadOnly"}}, false},
+		{"error", testAccounts(), "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := accountEntries(tt.accounts, nil)
+			err := listEntryRoles(zerologTestingContext, s, "token", entries, tt.roleFilter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("listEntryRoles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			for i, entry := range entries {
+				if !reflect.DeepEqual(entry.Roles, tt.want[i]) {
+					t.Errorf("listEntryRoles() %s roles = %v, want %v", entry.AccountID, entry.Roles, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWriteAccountEntries(t *testing.T) {
+	entries := []AccountEntry{
+		{AccountID: "111111111111", AccountName: "prod-app", EmailAddress: "prod@example.com", Roles: []string{"ReadOnly", "Admin"}},
+		{AccountID: "222222222222", AccountName: "dev, app", EmailAddress: "dev@example.com"},
+	}
+	tests := []struct {
+		name      string
+		output    string
+		withRoles bool
+		want      string
+		wantErr   bool
+	}{
+		{
+			"table", "table", false,
+			"ACCOUNT ID    ACCOUNT NAME  EMAIL\n111111111111  prod-app      prod@example.com\n222222222222  dev, app      dev@example.com\n",
+			false,
+		},
+		{
+			"table roles", "", true,
+			"ACCOUNT ID    ACCOUNT NAME  ROLE\n111111111111  prod-app      ReadOnly\n111111111111  prod-app      Admin\n",
+			false,
+		},
+		{
+			"csv", "csv", false,
+			"account_id,account_name,email_address\n111111111111,prod-app,prod@example.com\n222222222222,\"dev, app\",dev@example.com\n",
+			false,
+		},
+		{
+			"csv roles", "csv", true,
+			"account_id,account_name,email_address,role_name\n111111111111,prod-app,prod@example.com,ReadOnly\n111111111111,prod-app,prod@example.com,Admin\n",
+			false,
+		},
+		{
+			"yaml", "yaml", true,
+			"- account_id: \"111111111111\"\n  account_name: prod-app\n  email_address: prod@example.com\n  roles:\n  - ReadOnly\n  - Admin\n- account_id: \"222222222222\"\n  account_name: dev, app\n  email_address: dev@example.com\n",
+			false,
+		},
+		{"unknown", "xml", false, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := writeAccountEntries(&out, entries, tt.output, tt.withRoles)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("writeAccountEntries() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if out.String() != tt.want {
+				t.Errorf("writeAccountEntries() = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteAccountEntriesMetadata(t *testing.T) {
+	metadata := map[string]file.AccountMetadata{"111111111111": {Environment: "prod", Team: "payments", Aliases: []string{"pay"}}}
+	entries := accountEntries(testAccounts()[:2], metadata)
+
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{
+			"table", "table",
+			"ACCOUNT ID    ACCOUNT NAME  EMAIL  ENVIRONMENT  TEAM\n111111111111  prod-app             prod         payments\n222222222222  dev-app                           \n",
+		},
+		{
+			"csv", "csv",
+			"account_id,account_name,email_address,environment,team\n111111111111,prod-app,,prod,payments\n222222222222,dev-app,,,\n",
+		},
+		{
+			"yaml", "yaml",
+			"- account_id: \"111111111111\"\n  account_name: prod-app\n  email_address: \"\"\n  environment: prod\n  team: payments\n  aliases:\n  - pay\n- account_id: \"222222222222\"\n  account_name: dev-app\n  email_address: \"\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := writeAccountEntries(&b, entries, tt.output, false); err != nil {
+				t.Fatalf("writeAccountEntries() error = %v", err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("writeAccountEntries() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
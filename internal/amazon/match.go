@@ -0,0 +1,194 @@
+package amazon
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/rs/zerolog"
+
+	"ssoctx/internal/file"
+	"ssoctx/internal/terminal"
+)
+
+// UseAccountMetadata sets the metadata from config per account id, like aliases and environments
+// The account picker is grouped by environment when groupByEnvironment is set.
+func (c *Client) UseAccountMetadata(accounts map[string]file.AccountMetadata, groupByEnvironment bool) {
+	c.labels = terminal.AccountLabels{Metadata: accounts, GroupByEnvironment: groupByEnvironment}
+}
+
+// describeAccount returns the account id followed by its environment, team and aliases from config
+func (c *Client) describeAccount(accountID string) string {
+	metadata := c.labels.Metadata[accountID]
+	description := accountID
+	if len(metadata.Environment) > 0 {
+		description = fmt.Sprintf("%s [%s]", description, metadata.Environment)
+	}
+	if len(metadata.Team) > 0 {
+		description = fmt.Sprintf("%s team %s", description, metadata.Team)
+	}
+	if len(metadata.Aliases) > 0 {
+		description = fmt.Sprintf("%s aka %s", description, strings.Join(metadata.Aliases, ", "))
+	}
+	return description
+}
+
+// resolveAccountAndRole resolves the account and role patterns to an account id and role name
+// Patterns are only resolved when set, an ambiguous or unmatched pattern is fatal instead of prompting.
+func resolveAccountAndRole(ctx context.Context, s *Client, accessToken, accountPattern, rolePattern, accountID, roleName string) (string, string) {
+	logger := zerolog.Ctx(ctx)
+
+	if len(accountPattern) > 0 {
+		accounts, err := s.matchableAccounts(ctx, accessToken)
+		if err != nil {
+			logger.Fatal().Msgf("Encountered error in listAccounts: %v", err)
+		}
+		account, err := matchAccount(accounts, s.labels.Metadata, accountPattern)
+		if err != nil {
+			logger.Fatal().Msgf("%v", err)
+		}
+		accountID = aws.ToString(account.AccountId)
+		logger.Debug().Msgf("Account %q matched %s %s", accountPattern, aws.ToString(account.AccountName), accountID)
+	}
+
+	if len(rolePattern) > 0 {
+		if len(accountID) == 0 {
+			logger.Fatal().Msg("--role needs an account, set --account or --account-id")
+		}
+		roles, err := s.catalogRoles(ctx, accountID, accessToken)
+		if err != nil {
+			logger.Fatal().Msgf("Encountered error in listAvailableRoles: %v", err)
+		}
+		role, err := matchRole(roles.RoleList, rolePattern)
+		if err != nil {
+			logger.Fatal().Msgf("%v", err)
+		}
+		roleName = aws.ToString(role.RoleName)
+	}
+
+	return accountID, roleName
+}
+
+// matchableAccounts returns the cached accounts while within the catalog ttl, otherwise the listed accounts
+func (c *Client) matchableAccounts(ctx context.Context, accessToken string) ([]types.AccountInfo, error) {
+	if cached, fresh := c.catalogAccounts(ctx); fresh {
+		return cached, nil
+	}
+	listed, err := c.listAccounts(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	return listed.AccountList, nil
+}
+
+// matchAccount returns the single account matching the pattern
+// An exact id, name or alias wins over glob and regex matches of the name, id or aliases.
+func matchAccount(accounts []types.AccountInfo, metadata map[string]file.AccountMetadata, pattern string) (types.AccountInfo, error) {
+	names := func(account types.AccountInfo) []string {
+		id := aws.ToString(account.AccountId)
+		return append([]string{id, aws.ToString(account.AccountName)}, metadata[id].Aliases...)
+	}
+
+	var exact, matched []types.AccountInfo
+	for _, account := range accounts {
+		for _, name := range names(account) {
+			if strings.EqualFold(name, pattern) {
+				exact = append(exact, account)
+				break
+			}
+		}
+	}
+	if len(exact) == 0 {
+		match, err := patternMatcher(pattern)
+		if err != nil {
+			return types.AccountInfo{}, err
+		}
+		for _, account := range accounts {
+			for _, name := range names(account) {
+				if match(name) {
+					matched = append(matched, account)
+					break
+				}
+			}
+		}
+	} else {
+		matched = exact
+	}
+
+	switch len(matched) {
+	case 1:
+		return matched[0], nil
+	case 0:
+		return types.AccountInfo{}, fmt.Errorf("no account matches %q", pattern)
+	default:
+		candidates := make([]string, len(matched))
+		for i, account := range matched {
+			candidates[i] = fmt.Sprintf("%s %s", aws.ToString(account.AccountId), aws.ToString(account.AccountName))
+		}
+		return types.AccountInfo{}, ambiguousError("account", pattern, candidates)
+	}
+}
+
+// matchRole returns the single role matching the pattern, an exact name wins over glob and regex matches
+func matchRole(roles []types.RoleInfo, pattern string) (types.RoleInfo, error) {
+	var matched []types.RoleInfo
+	for _, role := range roles {
+		if strings.EqualFold(aws.ToString(role.RoleName), pattern) {
+			matched = append(matched, role)
+		}
+	}
+	if len(matched) == 0 {
+		match, err := patternMatcher(pattern)
+		if err != nil {
+			return types.RoleInfo{}, err
+		}
+		for _, role := range roles {
+			if match(aws.ToString(role.RoleName)) {
+				matched = append(matched, role)
+			}
+		}
+	}
+
+	switch len(matched) {
+	case 1:
+		return matched[0], nil
+	case 0:
+		return types.RoleInfo{}, fmt.Errorf("no role matches %q", pattern)
+	default:
+		candidates := make([]string, len(matched))
+		for i, role := range matched {
+			candidates[i] = aws.ToString(role.RoleName)
+		}
+		return types.RoleInfo{}, ambiguousError("role", pattern, candidates)
+	}
+}
+
+// patternMatcher returns a case insensitive matcher for a /regex/ or a glob pattern
+func patternMatcher(pattern string) (func(string) bool, error) {
+	if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile("(?i)" + pattern[1:len(pattern)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %w", pattern, err)
+		}
+		return re.MatchString, nil
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	pattern = strings.ToLower(pattern)
+	return func(name string) bool {
+		ok, _ := path.Match(pattern, strings.ToLower(name))
+		return ok
+	}, nil
+}
+
+// ambiguousError lists the candidates matching the pattern
+func ambiguousError(kind, pattern string, candidates []string) error {
+	sort.Strings(candidates)
+	return fmt.Errorf("%s %q is ambiguous, it matches:\n  %s", kind, pattern, strings.Join(candidates, "\n  "))
+}
@@ -0,0 +1,118 @@
+package amazon
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+
+	"ssoctx/internal/file"
+)
+
+func TestMatchAccount(t *testing.T) {
+	metadata := map[string]file.AccountMetadata{
+		"111111111111": {Aliases: []string{"payments-prod"}},
+	}
+	tests := []struct {
+		name    string
+		pattern string
+		want    string
+		wantErr string
+	}{
+		{"id", "222222222222", "222222222222", ""},
+		{"name", "dev-app", "222222222222", ""},
+		{"name ignoring case", "DEV-APP", "222222222222", ""},
+		{"alias", "payments-prod", "111111111111", ""},
+		{"glob", "*-data", "333333333333", ""},
+		{"glob on alias", "payments-*", "111111111111", ""},
+		{"regex", "/^dev/", "222222222222", ""},
+		{"ambiguous glob", "prod-*", "", "111111111111 prod-app\n  333333333333 prod-data"},
+		{"no match", "staging", "", `no account matches "staging"`},
+		{"invalid regex", "/(/", "", "invalid regex"},
+		{"invalid glob", "[", "", "invalid pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account, err := matchAccount(testAccounts(), metadata, tt.pattern)
+			if len(tt.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("matchAccount() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("matchAccount() error = %v", err)
+			}
+			if got := aws.ToString(account.AccountId); got != tt.want {
+				t.Errorf("matchAccount() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchRole(t *testing.T) {
+	roles := []types.RoleInfo{
+		{RoleName: aws.String("ReadOnly")},
+		{RoleName: aws.String("ReadOnlyBilling")},
+		{RoleName: aws.String("AdministratorAccess")},
+	}
+	tests := []struct {
+		name    string
+		pattern string
+		want    string
+		wantErr string
+	}{
+		{"exact wins over glob", "readonly", "ReadOnly", ""},
+		{"glob", "admin*", "AdministratorAccess", ""},
+		{"regex", "/billing$/", "ReadOnlyBilling", ""},
+		{"ambiguous", "Read*", "", "ReadOnly\n  ReadOnlyBilling"},
+		{"no match", "Developer", "", `no role matches "Developer"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role, err := matchRole(roles, tt.pattern)
+			if len(tt.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("matchRole() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("matchRole() error = %v", err)
+			}
+			if got := aws.ToString(role.RoleName); got != tt.want {
+				t.Errorf("matchRole() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveAccountAndRole(t *testing.T) {
+	tests := []struct {
+		name           string
+		accountPattern string
+		rolePattern    string
+		accountID      string
+		roleName       string
+		wantAccount    string
+		wantRole       string
+	}{
+		{"no patterns", "", "", "111111111111", "Admin", "111111111111", "Admin"},
+		{"account pattern", "dev-*", "", "", "", "222222222222", ""},
+		{"both patterns", "dev-app", "list*", "", "", "222222222222", "Listed"},
+		{"role pattern with account id", "", "/^listed$/", "333333333333", "", "333333333333", "Listed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempCredentialsCache(t)
+			calls := 0
+			c := catalogClient(testAccounts(), false, &calls)
+
+			accountID, roleName := resolveAccountAndRole(zerologTestingContext, c, "token", tt.accountPattern, tt.rolePattern, tt.accountID, tt.roleName)
+			if accountID != tt.wantAccount || roleName != tt.wantRole {
+				t.Errorf("resolveAccountAndRole() = %s %s, want %s %s", accountID, roleName, tt.wantAccount, tt.wantRole)
+			}
+		})
+	}
+}
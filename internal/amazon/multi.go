@@ -0,0 +1,155 @@
+package amazon
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/rs/zerolog"
+
+	"ssoctx/internal/file"
+	"ssoctx/internal/terminal"
+)
+
+// fetchCredentialsWorkers is how many role credentials are fetched at once
+const fetchCredentialsWorkers = 8
+
+// selectAccountRoles is the multi select of account and role pairs, replaced in tests
+var selectAccountRoles = terminal.NewMultiSelectForm[terminal.AccountRole]
+
+// multiProfile is a selected account and role written to a profile
+type multiProfile struct {
+	pair        terminal.AccountRole
+	profile     string
+	credentials *sso.GetRoleCredentialsOutput
+}
+
+// selectMulti selects several account and role pairs and writes a profile per pair named by the profile template
+// Role credentials are fetched concurrently and every profile is written at once, so nothing is written when one fails.
+func selectMulti(ctx context.Context, s *Client, accessToken string, inputs SelectFlagInputs) {
+	logger := zerolog.Ctx(ctx)
+
+	profileTemplate := inputs.ProfileTemplate
+	if len(profileTemplate) == 0 {
+		profileTemplate = DefaultProfileTemplate
+	}
+	tmpl, err := parseProfileTemplate(profileTemplate)
+	if err != nil {
+		logger.Fatal().Msgf("%v", err)
+	}
+
+	pairs, err := s.accountRolePairs(ctx, accessToken)
+	if err != nil {
+		logger.Fatal().Msgf("Encountered error listing accounts and roles: %v", err)
+	}
+	selected, err := terminal.SelectAccountRoles(pairs, s.labels, s.preferredAccountRoles(ctx), selectAccountRoles)
+	if err != nil {
+		logger.Fatal().Msgf("Encountered error in selectAccountRoles: %v", err)
+	}
+
+	profiles := make([]multiProfile, len(selected))
+	owners := map[string]string{}
+	for i, pair := range selected {
+		accountID, roleName := aws.ToString(pair.Account.AccountId), aws.ToString(pair.Role.RoleName)
+		name, err := profileName(tmpl, pair.Account, roleName, s.labels.Metadata[accountID])
+		if err != nil {
+			logger.Fatal().Msgf("%v", err)
+		}
+		if owner, ok := owners[name]; ok {
+			logger.Fatal().Msgf("Profile %s is named the same for %s and %s %s, change --profile-template", name, owner, accountID, roleName)
+		}
+		owners[name] = fmt.Sprintf("%s %s", accountID, roleName)
+
+		guardSelection(ctx, s, accessToken, accountID, roleName, name, inputs.Keys, inputs.Confirm)
+		output := file.OutputProcess
+		if inputs.Keys {
+			output = file.OutputKeys
+		}
+		enforcePolicy(ctx, s, accessToken, accountID, roleName, name, output)
+		profiles[i] = multiProfile{pair: pair, profile: name}
+	}
+
+	if err := fetchProfileCredentials(ctx, s, accessToken, inputs.StartURL, profiles); err != nil {
+		logger.Fatal().Msgf("Encountered error attempting to getRoleCredentials: %v", err)
+	}
+
+	written := make([]credentialsProfile, len(profiles))
+	for i, p := range profiles {
+		accountID, roleName := aws.ToString(p.pair.Account.AccountId), aws.ToString(p.pair.Role.RoleName)
+		template := getCredentialProcess(accountID, roleName, inputs.Region, inputs.StartURL)
+		if inputs.Keys {
+			template = getPersistedCredentials(p.credentials, inputs.Region)
+		}
+		written[i] = credentialsProfile{Profile: p.profile, Template: template}
+	}
+	writeAWSCredentialsProfiles(ctx, written)
+
+	for _, p := range profiles {
+		logger.Info().Msgf("Wrote profile %s for account %s with role %s",
+			p.profile, s.describeAccount(aws.ToString(p.pair.Account.AccountId)), aws.ToString(p.pair.Role.RoleName))
+	}
+}
+
+// accountRolePairs returns every account with each of its roles
+// Accounts are cached while within the catalog ttl, roles are listed concurrently like List.
+func (c *Client) accountRolePairs(ctx context.Context, accessToken string) ([]terminal.AccountRole, error) {
+	accounts, err := c.matchableAccounts(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	entries := accountEntries(accounts, c.labels.Metadata)
+	if err := listEntryRoles(ctx, c, accessToken, entries, ""); err != nil {
+		return nil, err
+	}
+
+	var pairs []terminal.AccountRole
+	for i, entry := range entries {
+		for _, role := range entry.Roles {
+			pairs = append(pairs, terminal.AccountRole{
+				Account: accounts[i],
+				Role:    types.RoleInfo{AccountId: accounts[i].AccountId, RoleName: aws.String(role)},
+			})
+		}
+	}
+	return pairs, nil
+}
+
+// fetchProfileCredentials gets the role credentials of every profile concurrently and caches them
+// The first error is returned after every fetch is done.
+func fetchProfileCredentials(ctx context.Context, s *Client, accessToken, startURL string, profiles []multiProfile) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		jobs     = make(chan int)
+	)
+	for w := 0; w < fetchCredentialsWorkers && w < len(profiles); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				accountID, roleName := aws.ToString(profiles[i].pair.Account.AccountId), aws.ToString(profiles[i].pair.Role.RoleName)
+				roleCredentials, err := s.getRolesCredentials(ctx, accountID, roleName, accessToken)
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = fmt.Errorf("account %s role %s: %w", accountID, roleName, err)
+					}
+					mu.Unlock()
+					continue
+				}
+				writeCachedCredentials(ctx, roleCacheKey(startURL, accountID, roleName), roleCredentials, s.safeguardedDuration(ctx, accountID, roleName))
+				profiles[i].credentials = roleCredentials
+			}
+		}()
+	}
+	for i := range profiles {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return firstErr
+}
@@ -0,0 +1,141 @@
+package amazon
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/charmbracelet/huh"
+	ini "gopkg.in/ini.v1"
+
+	"ssoctx/internal/terminal"
+)
+
+// useTempCredentialsFile points the credentials file to a file in a temp dir with the content
+func useTempCredentialsFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "credentials")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mockGetCredentialsFilePath = func() string { return path }
+	t.Cleanup(func() { mockGetCredentialsFilePath = nil })
+	return path
+}
+
+func TestSelectMulti(t *testing.T) {
+	useTempCredentialsCache(t)
+	path := useTempCredentialsFile(t, "[keep]\nregion = us-east-1\n")
+
+	var fetched atomic.Int32
+	c := NewSSOClient(&mockSSOClient{
+		ListAccountsAPI: func(ctx context.Context, params *sso.ListAccountsInput, optFns ...func(*sso.Options)) (*sso.ListAccountsOutput, error) {
+			return &sso.ListAccountsOutput{AccountList: testAccounts()[:2]}, nil
+		},
+		ListAccountRolesAPI: func(ctx context.Context, params *sso.ListAccountRolesInput, optFns ...func(*sso.Options)) (*sso.ListAccountRolesOutput, error) {
+			return &sso.ListAccountRolesOutput{RoleList: []types.RoleInfo{{RoleName: aws.String("ReadOnly")}, {RoleName: aws.String("Admin")}}}, nil
+		},
+		GetRoleCredentialsAPI: func(ctx context.Context, params *sso.GetRoleCredentialsInput, optFns ...func(*sso.Options)) (*sso.GetRoleCredentialsOutput, error) {
+			fetched.Add(1)
+			return fakeRoleCredentials(), nil
+		},
+	})
+
+	original := selectAccountRoles
+	defer func() { selectAccountRoles = original }()
+	var offered []string
+	selectAccountRoles = func(options []huh.Option[terminal.AccountRole], title string) ([]terminal.AccountRole, error) {
+		var selected []terminal.AccountRole
+		for _, option := range options {
+			offered = append(offered, option.Value.ID())
+			if aws.ToString(option.Value.Role.RoleName) == "ReadOnly" {
+				selected = append(selected, option.Value)
+			}
+		}
+		return selected, nil
+	}
+
+	selectMulti(zerologTestingContext, c, "token", SelectFlagInputs{StartURL: testCatalogURL, Region: "us-east-1"})
+
+	if len(offered) != 4 {
+		t.Errorf("offered %v, want every account and role", offered)
+	}
+	if fetched.Load() != 2 {
+		t.Errorf("fetched %d role credentials, want 2", fetched.Load())
+	}
+	creds, err := ini.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, profile := range []string{"keep", "prod-app-ReadOnly", "dev-app-ReadOnly"} {
+		if !creds.HasSection(profile) {
+			t.Errorf("credentials file has no profile %s, has %v", profile, creds.SectionStrings())
+		}
+	}
+	if process := creds.Section("dev-app-ReadOnly").Key("credential_process").String(); !strings.Contains(process, "-a 222222222222 -n ReadOnly") {
+		t.Errorf("dev-app-ReadOnly credential_process = %q", process)
+	}
+}
+
+func TestFetchProfileCredentials(t *testing.T) {
+	useTempCredentialsCache(t)
+	c := NewSSOClient(&mockSSOClient{
+		GetRoleCredentialsAPI: func(ctx context.Context, params *sso.GetRoleCredentialsInput, optFns ...func(*sso.Options)) (*sso.GetRoleCredentialsOutput, error) {
+			if aws.ToString(params.AccountId) == "333333333333" {
+				return nil, errors.New("forbidden")
+			}
+			return fakeRoleCredentials(), nil
+		},
+	})
+	profiles := make([]multiProfile, 3)
+	for i, account := range testAccounts() {
+		profiles[i] = multiProfile{pair: terminal.AccountRole{Account: account, Role: types.RoleInfo{RoleName: aws.String("ReadOnly")}}}
+	}
+
+	err := fetchProfileCredentials(zerologTestingContext, c, "token", testCatalogURL, profiles)
+	if err == nil || !strings.Contains(err.Error(), "333333333333") {
+		t.Fatalf("fetchProfileCredentials() error = %v, want the failed account", err)
+	}
+	if profiles[0].credentials == nil || profiles[1].credentials == nil {
+		t.Errorf("fetchProfileCredentials() did not fetch the accessible accounts")
+	}
+}
+
+func TestWriteAWSCredentialsProfiles(t *testing.T) {
+	path := useTempCredentialsFile(t, "[keep]\nregion = us-east-1\n[replace]\nregion = us-east-1\n")
+	if err := os.Chmod(path, 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	writeAWSCredentialsProfiles(zerologTestingContext, []credentialsProfile{
+		{Profile: "replace", Template: CredentialsTemplate{Region: "eu-west-1"}},
+		{Profile: "new", Template: CredentialsTemplate{Region: "us-west-2"}},
+	})
+
+	creds, err := ini.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for profile, region := range map[string]string{"keep": "us-east-1", "replace": "eu-west-1", "new": "us-west-2"} {
+		if got := creds.Section(profile).Key("region").String(); got != region {
+			t.Errorf("profile %s region = %q, want %q", profile, got, region)
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o640 {
+		t.Errorf("credentials file mode = %v, want kept 0640", info.Mode().Perm())
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("temp files left next to credentials: %v", entries)
+	}
+}
@@ -0,0 +1,124 @@
+package amazon
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"ssoctx/internal/file"
+)
+
+// accountIDGlob matches account patterns made of account id digits and globs only, which never match a name
+var accountIDGlob = regexp.MustCompile(`^[0-9*?]*$`)
+
+// outputNames describes the output modes in policy errors
+var outputNames = map[string]string{
+	file.OutputKeys:    "access keys in the credentials file",
+	file.OutputProcess: "credential_process",
+	file.OutputExec:    "exec",
+	file.OutputPrint:   "print-creds",
+	file.OutputConsole: "console sign-in",
+}
+
+// UsePolicy enforces the policy on start urls, written profiles and how credentials are handed out
+func (c *Client) UsePolicy(policy *file.Policy) {
+	c.policy = policy
+}
+
+// enforceStartURL exits when the policy does not permit the start url
+func enforceStartURL(ctx context.Context, s *Client, startURL string) {
+	if err := checkStartURL(s.policy, startURL); err != nil {
+		zerolog.Ctx(ctx).Fatal().Msgf("%v", err)
+	}
+}
+
+// enforcePolicy exits when the policy does not permit the account and role in the output mode,
+// or the profile written to is reserved, profile is empty when nothing is written
+func enforcePolicy(ctx context.Context, s *Client, accessToken, accountID, roleName, profile, output string) {
+	if err := checkPolicy(ctx, s, accessToken, accountID, roleName, profile, output); err != nil {
+		zerolog.Ctx(ctx).Fatal().Msgf("%v", err)
+	}
+}
+
+// checkPolicy returns the error enforcePolicy exits with, used by the credential helpers that report errors
+func checkPolicy(ctx context.Context, s *Client, accessToken, accountID, roleName, profile, output string) error {
+	if s.policy == nil {
+		return nil
+	}
+	if err := checkProfile(s.policy, profile); err != nil {
+		return err
+	}
+	if len(s.policy.Rules) == 0 {
+		return nil
+	}
+	names, named := s.lookupAccountNames(ctx, accessToken, accountID)
+	return checkOutput(ctx, s.policy, names, named, roleName, output)
+}
+
+// checkStartURL returns an error when start urls are restricted and the start url is not one of them
+func checkStartURL(policy *file.Policy, startURL string) error {
+	if policy == nil || len(policy.StartURLs) == 0 {
+		return nil
+	}
+	for _, permitted := range policy.StartURLs {
+		if strings.TrimSuffix(permitted, "/") == strings.TrimSuffix(startURL, "/") {
+			return nil
+		}
+	}
+	return fmt.Errorf("policy does not permit start url %q, permitted: %s", startURL, strings.Join(policy.StartURLs, ", "))
+}
+
+// checkProfile returns an error when the profile matches a reserved profile glob
+func checkProfile(policy *file.Policy, profile string) error {
+	if policy == nil || len(profile) == 0 {
+		return nil
+	}
+	for _, reserved := range policy.ReservedProfiles {
+		match, err := patternMatcher(reserved)
+		if err != nil || match(profile) {
+			return fmt.Errorf("policy reserves profile %q, set --profile", profile)
+		}
+	}
+	return nil
+}
+
+// checkOutput returns an error when the first rule matching the account and role does not allow the output mode
+// Accounts and roles without a matching rule are allowed every output mode.
+// When the account name is not known, a rule on account names that would deny the output mode fails closed,
+// since the rule might match the name.
+func checkOutput(ctx context.Context, policy *file.Policy, names []string, named bool, roleName, output string) error {
+	for _, rule := range policy.Rules {
+		if !patternMatches(ctx, rule.Role, roleName) {
+			continue
+		}
+		if !patternMatches(ctx, rule.Account, names...) {
+			if !named && !accountIDGlob.MatchString(rule.Account) && !allowsOutput(rule, output) {
+				return fmt.Errorf("policy rule for account %s cannot be checked without the name of account %s, run ssoctx list to cache account names",
+					rule.Account, strings.Join(names, " "))
+			}
+			continue
+		}
+		if allowsOutput(rule, output) {
+			return nil
+		}
+		if len(rule.Outputs) == 0 {
+			return fmt.Errorf("policy denies role %s in account %s", roleName, strings.Join(names, " "))
+		}
+		return fmt.Errorf("policy denies %s for role %s in account %s, allowed: %s",
+			outputNames[output], roleName, strings.Join(names, " "), strings.Join(rule.Outputs, ", "))
+	}
+	return nil
+}
+
+// allowsOutput returns true when the rule allows the output mode
+func allowsOutput(rule file.PolicyRule, output string) bool {
+	for _, allowed := range rule.Outputs {
+		if allowed == output {
+			return true
+		}
+	}
+	return false
+}
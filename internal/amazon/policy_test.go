@@ -0,0 +1,96 @@
+package amazon
+
+import (
+	"strings"
+	"testing"
+
+	"ssoctx/internal/file"
+)
+
+func TestCheckStartURL(t *testing.T) {
+	policy := &file.Policy{StartURLs: []string{"https://example.awsapps.com/start/"}}
+	tests := []struct {
+		name     string
+		policy   *file.Policy
+		startURL string
+		wantErr  bool
+	}{
+		{"no policy", nil, "https://other.awsapps.com/start", false},
+		{"any start url", &file.Policy{}, "https://other.awsapps.com/start", false},
+		{"permitted", policy, "https://example.awsapps.com/start", false},
+		{"not permitted", policy, "https://other.awsapps.com/start", true},
+		{"empty", policy, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkStartURL(tt.policy, tt.startURL); (err != nil) != tt.wantErr {
+				t.Errorf("checkStartURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckProfile(t *testing.T) {
+	policy := &file.Policy{ReservedProfiles: []string{"default", "org-*"}}
+	tests := []struct {
+		name    string
+		profile string
+		wantErr bool
+	}{
+		{"reserved", "default", true},
+		{"reserved glob", "org-audit", true},
+		{"not written", "", false},
+		{"allowed", "work", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkProfile(policy, tt.profile); (err != nil) != tt.wantErr {
+				t.Errorf("checkProfile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckOutput(t *testing.T) {
+	policy := &file.Policy{Rules: []file.PolicyRule{
+		{Account: "sandbox-*", Outputs: []string{file.OutputKeys, file.OutputProcess, file.OutputExec, file.OutputPrint}},
+		{Role: "*Admin*", Outputs: []string{file.OutputProcess, file.OutputExec}},
+		{Account: "audit"},
+		{Outputs: []string{file.OutputProcess, file.OutputExec, file.OutputPrint}},
+	}}
+	tests := []struct {
+		name    string
+		names   []string
+		named   bool
+		role    string
+		output  string
+		wantErr string
+	}{
+		{"sandbox keys", []string{"sandbox-1", "111111111111"}, true, "AdministratorAccess", file.OutputKeys, ""},
+		{"admin keys denied", []string{"prod-app", "222222222222"}, true, "AdministratorAccess", file.OutputKeys, "allowed: process, exec"},
+		{"admin print denied", []string{"prod-app", "222222222222"}, true, "AdministratorAccess", file.OutputPrint, "print-creds"},
+		{"admin process", []string{"prod-app", "222222222222"}, true, "AdministratorAccess", file.OutputProcess, ""},
+		{"admin console denied", []string{"prod-app", "222222222222"}, true, "AdministratorAccess", file.OutputConsole, "console sign-in"},
+		{"denied alias", []string{"333333333333", "audit"}, false, "ReadOnly", file.OutputProcess, "policy denies role ReadOnly"},
+		{"default rule", []string{"dev-app", "444444444444"}, true, "ReadOnly", file.OutputKeys, "access keys"},
+		{"unknown name fails closed", []string{"444444444444"}, false, "ReadOnly", file.OutputProcess, "cannot be checked without the name"},
+		{"unknown name allowing rule skipped", []string{"444444444444"}, false, "AdministratorAccess", file.OutputProcess, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkOutput(zerologTestingContext, policy, tt.names, tt.named, tt.role, tt.output)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Errorf("checkOutput() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkOutput() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+	if err := checkOutput(zerologTestingContext, &file.Policy{}, []string{"prod-app"}, true, "Admin", file.OutputKeys); err != nil {
+		t.Errorf("checkOutput() without rules error = %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package amazon
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/rs/zerolog"
+)
+
+// rolePreference is the ordered list of roles chosen without prompting
+type rolePreference struct {
+	roles    []string // role names or patterns, overridden per account by the account metadata
+	pickRole bool     // always prompt for the role
+}
+
+// UseRolePreference chooses the first available role of the ordered list instead of prompting
+// The list is set globally and per account in the account metadata, pickRole always prompts instead.
+func (c *Client) UseRolePreference(roles []string, pickRole bool) {
+	c.preference = rolePreference{roles: roles, pickRole: pickRole}
+}
+
+// prefersRoles returns true when roles may be chosen without prompting for any account
+func (c *Client) prefersRoles() bool {
+	if c.preference.pickRole {
+		return false
+	}
+	if len(c.preference.roles) > 0 {
+		return true
+	}
+	for _, metadata := range c.labels.Metadata {
+		if len(metadata.RolePreference) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// preferredRole returns the first role of the account preference available in roles
+func (c *Client) preferredRole(ctx context.Context, accountID string, roles []types.RoleInfo) (string, bool) {
+	if c.preference.pickRole {
+		return "", false
+	}
+	preference := c.preference.roles
+	if accountPreference := c.labels.Metadata[accountID].RolePreference; len(accountPreference) > 0 {
+		preference = accountPreference
+	}
+	return firstPreferredRole(ctx, roles, preference)
+}
+
+// firstPreferredRole returns the first role matching the preference in order, names and patterns ignore case
+func firstPreferredRole(ctx context.Context, roles []types.RoleInfo, preference []string) (string, bool) {
+	for _, pattern := range preference {
+		match, err := patternMatcher(pattern)
+		if err != nil {
+			zerolog.Ctx(ctx).Warn().Msgf("Skipping role preference %v", err)
+			continue
+		}
+		for _, role := range roles {
+			if match(aws.ToString(role.RoleName)) {
+				return aws.ToString(role.RoleName), true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,74 @@
+package amazon
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+
+	"ssoctx/internal/file"
+)
+
+func TestPreferredRole(t *testing.T) {
+	roles := []types.RoleInfo{
+		{RoleName: aws.String("AdministratorAccess")},
+		{RoleName: aws.String("Developer")},
+		{RoleName: aws.String("ReadOnlyAccess")},
+	}
+	metadata := map[string]file.AccountMetadata{
+		"222222222222": {RolePreference: []string{"Developer"}},
+	}
+	tests := []struct {
+		name       string
+		preference []string
+		pickRole   bool
+		accountID  string
+		want       string
+		wantOK     bool
+	}{
+		{"no preference", nil, false, "111111111111", "", false},
+		{"first available", []string{"ReadOnly", "Developer", "Admin"}, false, "111111111111", "Developer", true},
+		{"pattern", []string{"readonly*", "Developer"}, false, "111111111111", "ReadOnlyAccess", true},
+		{"no fallback available", []string{"ReadOnly", "Billing"}, false, "111111111111", "", false},
+		{"account preference", []string{"ReadOnly*"}, false, "222222222222", "Developer", true},
+		{"pick role", []string{"ReadOnly*"}, true, "111111111111", "", false},
+		{"invalid pattern skipped", []string{"[Read", "Developer"}, false, "111111111111", "Developer", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewSSOClient(&mockSSOClient{})
+			c.UseAccountMetadata(metadata, false)
+			c.UseRolePreference(tt.preference, tt.pickRole)
+
+			got, ok := c.preferredRole(zerologTestingContext, tt.accountID, roles)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("preferredRole() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestPrefersRoles(t *testing.T) {
+	tests := []struct {
+		name       string
+		preference []string
+		metadata   map[string]file.AccountMetadata
+		pickRole   bool
+		want       bool
+	}{
+		{"none", nil, nil, false, false},
+		{"global", []string{"ReadOnly"}, nil, false, true},
+		{"per account", nil, map[string]file.AccountMetadata{"111111111111": {RolePreference: []string{"ReadOnly"}}}, false, true},
+		{"pick role", []string{"ReadOnly"}, nil, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewSSOClient(&mockSSOClient{})
+			c.UseAccountMetadata(tt.metadata, false)
+			c.UseRolePreference(tt.preference, tt.pickRole)
+			if got := c.prefersRoles(); got != tt.want {
+				t.Errorf("prefersRoles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package amazon
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+
+	"ssoctx/internal/file"
+)
+
+// DefaultProfileTemplate names profiles written for several accounts and roles
+const DefaultProfileTemplate = "{{.AccountName | kebab}}-{{.RoleName}}"
+
+var nonKebabChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// profileFuncs are the functions available in profile name templates
+var profileFuncs = template.FuncMap{
+	"kebab": kebab,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// profileData is the data of a profile name template
+type profileData struct {
+	AccountID   string
+	AccountName string
+	RoleName    string
+	Environment string
+	Team        string
+	Alias       string // first alias of the account
+}
+
+// parseProfileTemplate parses a profile name template
+func parseProfileTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("profile").Funcs(profileFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid profile template %q: %w", text, err)
+	}
+	return tmpl, nil
+}
+
+// profileName renders the profile name of the account and role
+func profileName(tmpl *template.Template, account types.AccountInfo, roleName string, metadata file.AccountMetadata) (string, error) {
+	data := profileData{
+		AccountID:   aws.ToString(account.AccountId),
+		AccountName: aws.ToString(account.AccountName),
+		RoleName:    roleName,
+		Environment: metadata.Environment,
+		Team:        metadata.Team,
+	}
+	if len(metadata.Aliases) > 0 {
+		data.Alias = metadata.Aliases[0]
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("rendering profile name of %s %s: %w", data.AccountID, roleName, err)
+	}
+	name := strings.TrimSpace(b.String())
+	if len(name) == 0 || strings.ContainsAny(name, "[]\n") {
+		return "", fmt.Errorf("profile name %q of %s %s is not a valid profile name", name, data.AccountID, roleName)
+	}
+	return name, nil
+}
+
+// kebab lowercases the value and joins its words with dashes
+func kebab(value string) string {
+	return strings.Trim(nonKebabChars.ReplaceAllString(strings.ToLower(value), "-"), "-")
+}
@@ -0,0 +1,60 @@
+package amazon
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+
+	"ssoctx/internal/file"
+)
+
+func TestKebab(t *testing.T) {
+	tests := map[string]string{
+		"Prod App":          "prod-app",
+		"acme_7F3A--prod.":  "acme-7f3a-prod",
+		"already-kebab":     "already-kebab",
+		"  Spaces  Around ": "spaces-around",
+	}
+	for input, want := range tests {
+		if got := kebab(input); got != want {
+			t.Errorf("kebab(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestProfileName(t *testing.T) {
+	account := types.AccountInfo{AccountId: aws.String("111111111111"), AccountName: aws.String("Prod App")}
+	metadata := file.AccountMetadata{Aliases: []string{"payments"}, Environment: "prod"}
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{"default", DefaultProfileTemplate, "prod-app-ReadOnly", false},
+		{"metadata", "{{.Environment}}-{{.Alias}}-{{.RoleName | lower}}", "prod-payments-readonly", false},
+		{"id", "{{.AccountID}}_{{.RoleName}}", "111111111111_ReadOnly", false},
+		{"unknown field", "{{.Owner}}", "", true},
+		{"empty", "{{.Team}}", "", true},
+		{"invalid section", "[{{.RoleName}}]", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := parseProfileTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("parseProfileTemplate() error = %v", err)
+			}
+			got, err := profileName(tmpl, account, "ReadOnly", metadata)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("profileName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("profileName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if _, err := parseProfileTemplate("{{.AccountName"); err == nil {
+		t.Errorf("parseProfileTemplate() of unclosed action succeeded")
+	}
+}
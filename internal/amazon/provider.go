@@ -0,0 +1,35 @@
+package amazon
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+)
+
+// defaultRegion is used for regional service clients when no region is set
+const defaultRegion = "us-east-1"
+
+// roleCredentialsProvider returns a credentials provider for role credentials
+// This is used to create service clients that act as the selected role
+func roleCredentialsProvider(creds *sso.GetRoleCredentialsOutput) aws.CredentialsProvider {
+	return aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+		return aws.Credentials{
+			AccessKeyID:     *creds.RoleCredentials.AccessKeyId,
+			SecretAccessKey: *creds.RoleCredentials.SecretAccessKey,
+			SessionToken:    *creds.RoleCredentials.SessionToken,
+			Source:          ProjectFileName,
+			CanExpire:       true,
+			Expires:         time.UnixMilli(creds.RoleCredentials.Expiration),
+		}, nil
+	})
+}
+
+// serviceRegion returns the region or the default region when not set
+func serviceRegion(region string) string {
+	if len(region) == 0 {
+		return defaultRegion
+	}
+	return region
+}
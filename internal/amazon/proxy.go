@@ -0,0 +1,164 @@
+package amazon
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/rs/zerolog"
+
+	"ssoctx/internal/file"
+)
+
+// ProxyFlagInputs contains all needed inputs for Proxy
+type ProxyFlagInputs struct {
+	AccountID string
+	RoleName  string
+	StartURL  string
+	Region    string
+	Service   string // signing name of the upstream service, like es, execute-api or lambda
+	Upstream  string
+	Listen    string
+}
+
+// signingTransport signs every request with SigV4 before sending it upstream
+type signingTransport struct {
+	base        http.RoundTripper
+	signer      *v4.Signer
+	credentials aws.CredentialsProvider
+	service     string
+	region      string
+	now         func() time.Time
+	logger      *zerolog.Logger
+}
+
+// Proxy is used to run a local reverse proxy that SigV4 signs requests to an IAM authenticated upstream
+// Role credentials are refreshed from the cache or SSO as they expire
+func Proxy(ctx context.Context, o *OIDCClientAPI, s *Client, inputs ProxyFlagInputs) {
+	logger := zerolog.Ctx(ctx)
+
+	upstream, err := url.Parse(inputs.Upstream)
+	if err != nil || len(upstream.Scheme) == 0 || len(upstream.Host) == 0 {
+		logger.Fatal().Msgf("Invalid upstream url %q, expected scheme and host", inputs.Upstream)
+	}
+
+	accountID, roleName, _ := resolveRoleCredentials(ctx, o, s, inputs.AccountID, inputs.RoleName, inputs.StartURL, file.OutputProcess)
+	provider := aws.NewCredentialsCache(aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+		roleCredentials, err := cachedRoleCredentials(ctx, o, s, accountID, roleName, inputs.StartURL)
+		if err != nil {
+			return aws.Credentials{}, err
+		}
+		return roleCredentialsProvider(roleCredentials).Retrieve(ctx)
+	}), func(options *aws.CredentialsCacheOptions) {
+		options.ExpiryWindow = credentialsExpiryWindow
+	})
+
+	handler := newSigningProxy(ctx, inputs.Listen, upstream, inputs.Service, serviceRegion(inputs.Region), provider)
+	logger.Info().Msgf("Proxying http://%s to %s as %s/%s", inputs.Listen, upstream, accountID, roleName)
+	server := &http.Server{
+		Addr:              inputs.Listen,
+		Handler:           handler,
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	if err := server.ListenAndServe(); err != nil {
+		logger.Fatal().Msgf("Encountered error running proxy: %v", err)
+	}
+}
+
+// newSigningProxy returns a reverse proxy to upstream that signs requests for the service and region
+// Requests for other hosts than the listen address or localhost are rejected, so a page using DNS rebinding
+// cannot send signed requests through the proxy.
+func newSigningProxy(ctx context.Context, listen string, upstream *url.URL, service, region string, creds aws.CredentialsProvider) http.Handler {
+	logger := zerolog.Ctx(ctx)
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(upstream)
+			// the signature replaces any authorization sent by the browser
+			r.Out.Header.Del("Authorization")
+		},
+		Transport: &signingTransport{
+			base:        http.DefaultTransport,
+			signer:      v4.NewSigner(),
+			credentials: creds,
+			service:     service,
+			region:      region,
+			now:         time.Now,
+			logger:      logger,
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			logger.Error().Msgf("Encountered error proxying %s %s: %v", r.Method, r.URL.Path, err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !allowedProxyHost(r.Host, listen) {
+			logger.Warn().Msgf("Rejected proxy request for host %q, expected %s or localhost", r.Host, listen)
+			w.WriteHeader(http.StatusMisdirectedRequest)
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	})
+}
+
+// allowedProxyHost returns true when the request host is the listen address or a loopback name on its port
+func allowedProxyHost(host, listen string) bool {
+	if strings.EqualFold(host, listen) {
+		return true
+	}
+	listenHost, listenPort, err := net.SplitHostPort(listen)
+	if err != nil {
+		return false
+	}
+	name, port, err := net.SplitHostPort(host)
+	if err != nil {
+		name, port = host, "80"
+	}
+	if port != listenPort {
+		return false
+	}
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	if name == "localhost" || strings.EqualFold(name, listenHost) {
+		return true
+	}
+	ip := net.ParseIP(name)
+	return ip != nil && ip.IsLoopback()
+}
+
+// RoundTrip signs the request with the current credentials and sends it
+func (t *signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	payloadHash := emptyPayloadHash
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(sum[:])
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+	}
+
+	creds, err := t.credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve role credentials: %w", err)
+	}
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if err := t.signer.SignHTTP(ctx, creds, req, payloadHash, t.service, t.region, t.now()); err != nil {
+		return nil, fmt.Errorf("unable to sign request: %w", err)
+	}
+	t.logger.Debug().Msgf("Signed %s %s for %s", req.Method, req.URL.Path, t.service)
+	return t.base.RoundTrip(req)
+}
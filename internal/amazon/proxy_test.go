@@ -0,0 +1,135 @@
+package amazon
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// verifySignature re-signs the signed headers of the request and compares the authorization
+func verifySignature(t *testing.T, r *http.Request, body string) {
+	t.Helper()
+	authorization := r.Header.Get("Authorization")
+	_, signedHeaders, ok := strings.Cut(authorization, "SignedHeaders=")
+	if !ok {
+		t.Fatalf("request is not signed: %q", authorization)
+	}
+	signedHeaders, _, _ = strings.Cut(signedHeaders, ",")
+	signingTime, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		t.Fatalf("invalid X-Amz-Date: %v", err)
+	}
+
+	req, _ := http.NewRequest(r.Method, "http://"+r.Host+r.URL.RequestURI(), strings.NewReader(body))
+	for _, header := range strings.Split(signedHeaders, ";") {
+		if header != "host" {
+			req.Header.Set(header, r.Header.Get(header))
+		}
+	}
+	req.Header.Del("X-Amz-Date")
+	req.Header.Del("X-Amz-Security-Token")
+	creds, _ := roleCredentialsProvider(fakeRoleCredentials()).Retrieve(zerologTestingContext)
+	if err := v4.NewSigner().SignHTTP(zerologTestingContext, creds, req, r.Header.Get("X-Amz-Content-Sha256"), "es", "eu-west-1", signingTime); err != nil {
+		t.Fatal(err)
+	}
+	if got := req.Header.Get("Authorization"); got != authorization {
+		t.Errorf("signature mismatch\n got: %s\nwant: %s", authorization, got)
+	}
+}
+
+func TestSigningProxy(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"get", http.MethodGet, "/_dashboards/app/home?x=1&a=b", ""},
+		{"post", http.MethodPost, "/index/_search", `{"query":{"match_all":{}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if string(body) != tt.body {
+					t.Errorf("upstream body = %q, want %q", body, tt.body)
+				}
+				verifySignature(t, r, tt.body)
+				w.WriteHeader(http.StatusTeapot)
+			}))
+			defer upstream.Close()
+
+			upstreamURL, _ := url.Parse(upstream.URL)
+			proxy := httptest.NewUnstartedServer(nil)
+			proxy.Config.Handler = newSigningProxy(zerologTestingContext, proxy.Listener.Addr().String(), upstreamURL, "es", "eu-west-1", roleCredentialsProvider(fakeRoleCredentials()))
+			proxy.Start()
+			defer proxy.Close()
+
+			req, _ := http.NewRequest(tt.method, proxy.URL+tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Basic browser")
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusTeapot {
+				t.Errorf("proxy status = %v, want %v", resp.StatusCode, http.StatusTeapot)
+			}
+		})
+	}
+}
+
+func TestSigningProxyRejectsOtherHosts(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("request for %s reached the upstream", r.Host)
+	}))
+	defer upstream.Close()
+
+	upstreamURL, _ := url.Parse(upstream.URL)
+	proxy := httptest.NewUnstartedServer(nil)
+	proxy.Config.Handler = newSigningProxy(zerologTestingContext, proxy.Listener.Addr().String(), upstreamURL, "es", "eu-west-1", roleCredentialsProvider(fakeRoleCredentials()))
+	proxy.Start()
+	defer proxy.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, proxy.URL+"/", nil)
+	req.Host = "attacker.example.com"
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMisdirectedRequest {
+		t.Errorf("proxy status = %v, want %v", resp.StatusCode, http.StatusMisdirectedRequest)
+	}
+}
+
+func TestAllowedProxyHost(t *testing.T) {
+	tests := []struct {
+		host   string
+		listen string
+		want   bool
+	}{
+		{"127.0.0.1:8080", "127.0.0.1:8080", true},
+		{"localhost:8080", "127.0.0.1:8080", true},
+		{"LOCALHOST.:8080", ":8080", true},
+		{"[::1]:8080", "localhost:8080", true},
+		{"localhost:9090", "127.0.0.1:8080", false},
+		{"localhost", "127.0.0.1:8080", false},
+		{"localhost", "127.0.0.1:80", true},
+		{"attacker.example.com:8080", "127.0.0.1:8080", false},
+		{"192.168.1.5:8080", "0.0.0.0:8080", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host+"/"+tt.listen, func(t *testing.T) {
+			if got := allowedProxyHost(tt.host, tt.listen); got != tt.want {
+				t.Errorf("allowedProxyHost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
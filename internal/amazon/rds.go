@@ -0,0 +1,154 @@
+package amazon
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/rs/zerolog"
+
+	"ssoctx/internal/file"
+)
+
+const (
+	rdsSigningName   = "rds-db"
+	rdsTokenExpires  = "900"
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+var (
+	pgpassFilePath = actualPGPassFilePath
+
+	rdsHostPattern = regexp.MustCompile(`\.([a-z]{2}(-gov)?-[a-z]+-\d)\.rds\.amazonaws\.com(\.cn)?$`)
+)
+
+// RDSFlagInputs contains all needed inputs for RDSToken
+type RDSFlagInputs struct {
+	AccountID string
+	RoleName  string
+	StartURL  string
+	Region    string
+	Host      string
+	Port      int
+	User      string
+	Export    string // pg or mysql to output an export of the password variable
+	PGPass    bool   // write the token to the .pgpass file
+}
+
+// RDSToken is used to output an RDS IAM database auth token
+// The token is a SigV4 presigned connect request created locally with the role credentials
+func RDSToken(ctx context.Context, o *OIDCClientAPI, s *Client, inputs RDSFlagInputs) {
+	logger := zerolog.Ctx(ctx)
+
+	_, _, roleCredentials := resolveRoleCredentials(ctx, o, s, inputs.AccountID, inputs.RoleName, inputs.StartURL, file.OutputProcess)
+
+	region := rdsRegion(inputs.Host, inputs.Region)
+	token, err := rdsAuthToken(ctx, roleCredentials, region, inputs.Host, inputs.Port, inputs.User, time.Now())
+	if err != nil {
+		logger.Fatal().Msgf("Encountered error creating rds auth token: %v", err)
+	}
+
+	if inputs.PGPass {
+		if err := writePGPassEntry(inputs.Host, inputs.Port, inputs.User, token); err != nil {
+			logger.Fatal().Msgf("Encountered error writing pgpass entry: %v", err)
+		}
+		logger.Info().Msgf("Wrote pgpass entry for %s@%s:%d", inputs.User, inputs.Host, inputs.Port)
+		return
+	}
+
+	output, err := rdsTokenOutput(inputs.Export, token)
+	if err != nil {
+		logger.Fatal().Msgf("%v", err)
+	}
+	fmt.Println(output)
+}
+
+// rdsAuthToken presigns the rds-db connect request for the user
+func rdsAuthToken(ctx context.Context, creds *sso.GetRoleCredentialsOutput, region, host string, port int, user string, signingTime time.Time) (string, error) {
+	query := url.Values{}
+	query.Set("Action", "connect")
+	query.Set("DBUser", user)
+	query.Set("X-Amz-Expires", rdsTokenExpires)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("https://%s:%d/?%s", host, port, query.Encode()), nil)
+	if err != nil {
+		return "", err
+	}
+	awsCreds, err := roleCredentialsProvider(creds).Retrieve(ctx)
+	if err != nil {
+		return "", err
+	}
+	signed, _, err := v4.NewSigner().PresignHTTP(ctx, awsCreds, req, emptyPayloadHash, rdsSigningName, serviceRegion(region), signingTime)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(signed, "https://"), nil
+}
+
+// rdsRegion returns the region of an rds endpoint or the fallback for other hosts
+func rdsRegion(host, fallback string) string {
+	if matches := rdsHostPattern.FindStringSubmatch(host); matches != nil {
+		return matches[1]
+	}
+	return fallback
+}
+
+// rdsTokenOutput formats the token as is or as an export for the database client
+func rdsTokenOutput(export, token string) (string, error) {
+	switch export {
+	case "":
+		return token, nil
+	case "pg", "postgres":
+		return fmt.Sprintf("export PGPASSWORD='%s'", token), nil
+	case "mysql":
+		return fmt.Sprintf("export MYSQL_PWD='%s'", token), nil
+	default:
+		return "", fmt.Errorf("unknown export %q, expected pg or mysql", export)
+	}
+}
+
+func actualPGPassFilePath() string {
+	if path := os.Getenv("PGPASSFILE"); len(path) > 0 {
+		return path
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".pgpass")
+}
+
+// writePGPassEntry adds or replaces the .pgpass entry for the host, port and user
+func writePGPassEntry(host string, port int, user, token string) error {
+	prefix := fmt.Sprintf("%s:%d:*:%s:", escapePGPass(host), port, escapePGPass(user))
+	path := pgpassFilePath()
+
+	var lines []string
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if len(line) == 0 || strings.HasPrefix(line, prefix) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, prefix+escapePGPass(token))
+
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		return err
+	}
+	// libpq ignores the file unless it is only readable by the owner
+	return os.Chmod(path, 0o600)
+}
+
+// escapePGPass escapes the separators of a .pgpass field
+func escapePGPass(field string) string {
+	return strings.NewReplacer(`\`, `\\`, `:`, `\:`).Replace(field)
+}
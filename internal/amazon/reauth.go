@@ -0,0 +1,86 @@
+package amazon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/mattn/go-isatty"
+	"github.com/rs/zerolog"
+)
+
+// isInteractive returns true when a login can be completed by the user, replaced in tests
+var isInteractive = func() bool {
+	return isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd())
+}
+
+// revokedTokenCodes are the error codes of sso calls made with a revoked or invalid access token
+var revokedTokenCodes = map[string]bool{
+	"UnauthorizedException": true,
+	"ExpiredTokenException": true,
+}
+
+// reauthentication is the state of logging in again when the cached access token is revoked
+type reauthentication struct {
+	oidc    *OIDCClientAPI
+	mu      sync.Mutex
+	renewed map[string]string // access token per revoked access token
+}
+
+// UseReauthentication logs in again with the oidc client once when the portal rejects the cached access token
+// Sessions are revoked server side while the cached token still looks valid, like after permission set changes.
+func (c *Client) UseReauthentication(o *OIDCClientAPI) {
+	c.reauth = &reauthentication{oidc: o, renewed: map[string]string{}}
+}
+
+// currentToken returns the access token renewed for a revoked access token, otherwise the access token
+func (c *Client) currentToken(accessToken string) string {
+	if c.reauth == nil {
+		return accessToken
+	}
+	c.reauth.mu.Lock()
+	defer c.reauth.mu.Unlock()
+	if renewed, ok := c.reauth.renewed[accessToken]; ok {
+		return renewed
+	}
+	return accessToken
+}
+
+// renewToken returns a new access token to retry with when err is a revoked token error
+// The cached token is invalidated and the login flow is run once, failing fast when not interactive.
+// Otherwise err is returned.
+func (c *Client) renewToken(ctx context.Context, accessToken string, err error) (string, error) {
+	if c.reauth == nil || !revokedTokenCodes[GetAWSErrorCode(ctx, err)] {
+		return "", err
+	}
+	logger := zerolog.Ctx(ctx)
+	c.reauth.mu.Lock()
+	defer c.reauth.mu.Unlock()
+
+	if renewed, ok := c.reauth.renewed[accessToken]; ok {
+		// renewed by a concurrent call
+		return renewed, nil
+	}
+	if len(c.reauth.renewed) > 0 {
+		// the renewed token was rejected as well
+		return "", err
+	}
+
+	destination := clientInfoFileDestination(c.reauth.oidc.url)
+	if removeErr := os.Remove(destination); removeErr != nil && !os.IsNotExist(removeErr) {
+		logger.Debug().Msgf("Encountered error removing revoked access token: %v", removeErr)
+	}
+	if !isInteractive() {
+		return "", fmt.Errorf("access token was revoked, run %s select to login again: %w", ProjectFileName, err)
+	}
+
+	logger.Warn().Msg("Access token was revoked, logging in again")
+	clientInformation, loginErr := c.reauth.oidc.processClientInformation(ctx, destination)
+	if loginErr != nil {
+		return "", loginErr
+	}
+	writeSecretToFile(ctx, &clientInformation, destination)
+	c.reauth.renewed[accessToken] = clientInformation.AccessToken
+	return clientInformation.AccessToken, nil
+}
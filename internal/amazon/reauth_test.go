@@ -0,0 +1,126 @@
+package amazon
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	smithy "github.com/aws/smithy-go"
+)
+
+// loginOIDCClient completes the device authorization immediately with the access token
+func loginOIDCClient(token string, logins *int) *OIDCClientAPI {
+	return NewOIDCClient(&mockOIDCClient{
+		RegisterClientAPI: func(ctx context.Context, params *ssooidc.RegisterClientInput, optFns ...func(*ssooidc.Options)) (*ssooidc.RegisterClientOutput, error) {
+			*logins++
+			return &ssooidc.RegisterClientOutput{ClientId: aws.String(mockClientID), ClientSecret: aws.String(mockClientSecret)}, nil
+		},
+		StartDeviceAuthorizationAPI: func(ctx context.Context, params *ssooidc.StartDeviceAuthorizationInput, optFns ...func(*ssooidc.Options)) (*ssooidc.StartDeviceAuthorizationOutput, error) {
+			return &ssooidc.StartDeviceAuthorizationOutput{DeviceCode: aws.String(code), VerificationUriComplete: aws.String(uriComplete)}, nil
+		},
+		CreateTokenAPI: func(ctx context.Context, params *ssooidc.CreateTokenInput, optFns ...func(*ssooidc.Options)) (*ssooidc.CreateTokenOutput, error) {
+			return &ssooidc.CreateTokenOutput{AccessToken: aws.String(token)}, nil
+		},
+	}, testCatalogURL)
+}
+
+// tokenSSOClient accepts only the valid access token
+func tokenSSOClient(valid string) *mockSSOClient {
+	unauthorized := &smithy.GenericAPIError{Code: "UnauthorizedException", Message: "Session token not found or invalid"}
+	return &mockSSOClient{
+		GetRoleCredentialsAPI: func(ctx context.Context, params *sso.GetRoleCredentialsInput, optFns ...func(*sso.Options)) (*sso.GetRoleCredentialsOutput, error) {
+			if aws.ToString(params.AccessToken) != valid {
+				return nil, unauthorized
+			}
+			return fakeRoleCredentials(), nil
+		},
+		ListAccountsAPI: func(ctx context.Context, params *sso.ListAccountsInput, optFns ...func(*sso.Options)) (*sso.ListAccountsOutput, error) {
+			if aws.ToString(params.AccessToken) != valid {
+				return nil, unauthorized
+			}
+			return &sso.ListAccountsOutput{AccountList: testAccounts()}, nil
+		},
+		ListAccountRolesAPI: func(ctx context.Context, params *sso.ListAccountRolesInput, optFns ...func(*sso.Options)) (*sso.ListAccountRolesOutput, error) {
+			if aws.ToString(params.AccessToken) != valid {
+				return nil, unauthorized
+			}
+			return &sso.ListAccountRolesOutput{RoleList: []types.RoleInfo{{RoleName: aws.String("Admin")}}}, nil
+		},
+	}
+}
+
+func TestClient_Reauthentication(t *testing.T) {
+	tests := []struct {
+		name        string
+		interactive bool
+		valid       string // token accepted by the portal
+		login       string // token returned by logging in
+		wantErr     string
+		wantLogins  int
+	}{
+		{"renewed", true, "renewed", "renewed", "", 1},
+		{"non interactive", false, "renewed", "renewed", "run ssoctx select to login again", 0},
+		{"renewed token rejected", true, "other", "renewed", "UnauthorizedException", 1},
+		{"valid token", true, "revoked", "renewed", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenPath := filepath.Join(t.TempDir(), "access-token.json")
+			if err := os.WriteFile(tokenPath, []byte(`{"AccessToken":"revoked"}`), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			mockClientInfoFileDestination = func(string) string { return tokenPath }
+			defer func() { mockClientInfoFileDestination = nil }()
+			original := isInteractive
+			isInteractive = func() bool { return tt.interactive }
+			defer func() { isInteractive = original }()
+
+			logins := 0
+			c := NewSSOClient(tokenSSOClient(tt.valid))
+			c.UseReauthentication(loginOIDCClient(tt.login, &logins))
+
+			_, err := c.getRolesCredentials(zerologTestingContext, "111111111111", "Admin", "revoked")
+			if len(tt.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("getRolesCredentials() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("getRolesCredentials() error = %v", err)
+			}
+			if logins != tt.wantLogins {
+				t.Errorf("logged in %d times, want %d", logins, tt.wantLogins)
+			}
+			if tt.wantLogins == 0 && tt.valid != "revoked" {
+				if _, err := os.Stat(tokenPath); !os.IsNotExist(err) {
+					t.Errorf("revoked access token not invalidated: %v", err)
+				}
+			}
+
+			// later calls with the revoked token use the renewed token without logging in again
+			_, listErr := c.listAvailableRoles(zerologTestingContext, "111111111111", "revoked")
+			_, accountsErr := c.listAccounts(zerologTestingContext, "revoked")
+			if (listErr == nil) != (err == nil) || (accountsErr == nil) != (err == nil) {
+				t.Errorf("listing errors = %v, %v, want like getRolesCredentials %v", listErr, accountsErr, err)
+			}
+			if logins != tt.wantLogins {
+				t.Errorf("logged in %d times after listing, want %d", logins, tt.wantLogins)
+			}
+		})
+	}
+}
+
+func TestClient_RenewTokenOtherErrors(t *testing.T) {
+	c := NewSSOClient(&mockSSOClient{})
+	c.UseReauthentication(NewOIDCClient(&mockOIDCClient{}, testCatalogURL))
+	err := errors.New("timeout")
+	if _, got := c.renewToken(zerologTestingContext, "token", err); !errors.Is(got, err) {
+		t.Errorf("renewToken() = %v, want %v", got, err)
+	}
+}
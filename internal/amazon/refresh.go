@@ -6,59 +6,51 @@ import (
 
 	"github.com/rs/zerolog"
 
-	"ssoctx/internal/terminal"
+	"ssoctx/internal/file"
 )
 
 // RefreshFlagInputs contains all needed inputs for Credentials
 type RefreshFlagInputs struct {
 	AccountID string
 	RoleName  string
+	Account   string // account name, alias, id or pattern resolved to AccountID
+	Role      string // role name pattern resolved to RoleName
 	StartURL  string
 	Region    string
 	Profile   string
 	Keys      bool
+	Confirm   string // account name, id or alias typed ahead for safeguarded accounts
 }
 
 // Credentials is used to refresh credentials
 func Credentials(ctx context.Context, o *OIDCClientAPI, s *Client, inputs RefreshFlagInputs) {
 	logger := zerolog.Ctx(ctx)
+	enforceStartURL(ctx, s, inputs.StartURL)
 
 	destination := clientInfoFileDestination(inputs.StartURL)
 	clientInformation, err := readClientInformation(ctx, destination)
 	if err != nil || clientInformation.StartURL != inputs.StartURL {
 		clientInformation, _ = o.processClientInformation(ctx, destination)
 	}
-	writeStructToFile(ctx, &clientInformation, destination)
+	writeSecretToFile(ctx, &clientInformation, destination)
 	logger.Printf("Using Start URL %s", clientInformation.StartURL)
 
-	if len(inputs.AccountID) == 0 && len(inputs.RoleName) == 0 {
+	inputs.AccountID, inputs.RoleName = resolveAccountAndRole(ctx, s, clientInformation.AccessToken, inputs.Account, inputs.Role, inputs.AccountID, inputs.RoleName)
+
+	checkAccountID(ctx, s, clientInformation.AccessToken, inputs.AccountID)
+	if len(inputs.AccountID) == 0 || len(inputs.RoleName) == 0 {
 		logger.Info().Msg("No account-id or role-name provided.")
 		logger.Info().Msgf("Refreshing credentials from access to profile: %s", inputs.Profile)
 
-		accountsOutput, err := s.listAccounts(ctx, clientInformation.AccessToken)
-		if err != nil {
-			logger.Fatal().Msgf("Encountered error in listAccounts: %v", err)
-		}
-		accountInfo, err := terminal.SelectAccount(accountsOutput, terminal.NewSelectForm)
-		if err != nil {
-			logger.Fatal().Msgf("Encountered error in selectAccount: %v", err)
-		}
-		listRolesOutput, err := s.listAvailableRoles(
-			ctx,
-			*accountInfo.AccountId,
-			clientInformation.AccessToken,
-		)
-		if err != nil {
-			logger.Fatal().Msgf("Encountered error in listAvailableRoles: %v", err)
-		}
-		roleInfo, err := terminal.SelectRole(listRolesOutput, terminal.NewSelectForm)
-		if err != nil {
-			logger.Fatal().Msgf("Encountered error in selectRole: %v", err)
-		}
-		inputs.RoleName = *roleInfo.RoleName
-		inputs.AccountID = *accountInfo.AccountId
+		inputs.AccountID, inputs.RoleName = selectAccountAndRole(ctx, s, clientInformation.AccessToken, inputs.AccountID, inputs.RoleName)
+	}
+	guardSelection(ctx, s, clientInformation.AccessToken, inputs.AccountID, inputs.RoleName, inputs.Profile, inputs.Keys, inputs.Confirm)
+	if inputs.Keys {
+		enforcePolicy(ctx, s, clientInformation.AccessToken, inputs.AccountID, inputs.RoleName, inputs.Profile, file.OutputKeys)
+	} else {
+		enforcePolicy(ctx, s, clientInformation.AccessToken, inputs.AccountID, inputs.RoleName, inputs.Profile, file.OutputProcess)
 	}
-	logger.Info().Msgf("Refreshing for account %s with permission set role %s", inputs.AccountID, inputs.RoleName)
+	logger.Info().Msgf("Refreshing for account %s with permission set role %s", s.describeAccount(inputs.AccountID), inputs.RoleName)
 
 	roleCredentials, err := s.getRolesCredentials(
 		ctx,
@@ -66,7 +58,7 @@ func Credentials(ctx context.Context, o *OIDCClientAPI, s *Client, inputs Refres
 		inputs.RoleName,
 		clientInformation.AccessToken,
 	)
-	if err != nil {
+	if err = checkRoleCredentialsError(ctx, s, clientInformation.AccessToken, inputs.AccountID, inputs.RoleName, err); err != nil {
 		logger.Fatal().Msgf("Something went wrong: %q", err)
 	}
 
@@ -82,7 +74,7 @@ func Credentials(ctx context.Context, o *OIDCClientAPI, s *Client, inputs Refres
 		writeAWSCredentialsFile(ctx, &template, inputs.Profile)
 	}
 
-	logger.Info().Msgf("Successful retrieved credentials for account: %s", inputs.AccountID)
+	logger.Info().Msgf("Successful retrieved credentials for account: %s", s.describeAccount(inputs.AccountID))
 	logger.Info().Msgf("Assumed role: %s", inputs.RoleName)
 	logger.Info().Msgf(
 		"Credentials expire at: %s",
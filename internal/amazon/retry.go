@@ -0,0 +1,46 @@
+package amazon
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+)
+
+// listMaxAttempts is the number of attempts of list calls, which large organizations often get throttled on
+const listMaxAttempts = 10
+
+// throttleFunc is called before a throttled call is retried
+type throttleFunc func(operation string, attempt int, delay time.Duration)
+
+// throttleRetryer reports throttled retries of an operation
+type throttleRetryer struct {
+	aws.Retryer
+	operation  string
+	onThrottle throttleFunc
+}
+
+// RetryDelay returns the delay of the retryer and reports it when the error is throttling
+func (r throttleRetryer) RetryDelay(attempt int, err error) (time.Duration, error) {
+	delay, delayErr := r.Retryer.RetryDelay(attempt, err)
+	if delayErr == nil && r.onThrottle != nil && retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err) == aws.TrueTernary {
+		r.onThrottle(r.operation, attempt, delay)
+	}
+	return delay, delayErr
+}
+
+// withThrottleRetries raises the attempts of the operation and reports throttled retries to onThrottle
+func withThrottleRetries(operation string, onThrottle throttleFunc) func(*sso.Options) {
+	return func(o *sso.Options) {
+		retryer := o.Retryer
+		if retryer == nil {
+			retryer = retry.NewStandard()
+		}
+		o.Retryer = throttleRetryer{
+			Retryer:    retry.AddWithMaxAttempts(retryer, listMaxAttempts),
+			operation:  operation,
+			onThrottle: onThrottle,
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package amazon
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+)
+
+func TestListAccountPagesThrottled(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case calls == 1:
+			w.Header().Set("X-Amzn-ErrorType", "TooManyRequestsException")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"message":"slow down"}`)
+		case r.URL.Query().Get("next_token") == "":
+			fmt.Fprint(w, `{"accountList":[{"accountId":"111111111111","accountName":"one"}],"nextToken":"page2"}`)
+		default:
+			fmt.Fprint(w, `{"accountList":[{"accountId":"222222222222","accountName":"two"}]}`)
+		}
+	}))
+	defer server.Close()
+
+	client := NewSSOClient(sso.New(sso.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		Retryer: retry.NewStandard(func(o *retry.StandardOptions) {
+			o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+		}),
+	}))
+
+	var throttled []string
+	var accounts []types.AccountInfo
+	err := client.listAccountPages(zerologTestingContext, "token", func(operation string, attempt int, delay time.Duration) {
+		throttled = append(throttled, fmt.Sprintf("%s %d", operation, attempt))
+	}, func(page []types.AccountInfo) {
+		accounts = append(accounts, page...)
+	})
+	if err != nil {
+		t.Fatalf("listAccountPages() error = %v", err)
+	}
+	if len(accounts) != 2 || *accounts[1].AccountId != "222222222222" {
+		t.Errorf("listAccountPages() accounts = %v, want both pages", accounts)
+	}
+	if len(throttled) != 1 || throttled[0] != "ListAccounts 1" {
+		t.Errorf("listAccountPages() throttled = %v, want one ListAccounts retry", throttled)
+	}
+}
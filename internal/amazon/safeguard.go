@@ -0,0 +1,186 @@
+package amazon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/rs/zerolog"
+
+	"ssoctx/internal/file"
+	"ssoctx/internal/terminal"
+)
+
+// defaultProfile is the profile used by aws clients when none is set
+const defaultProfile = "default"
+
+var (
+	// confirmSafeguard asks to type the account before continuing, replaced in tests
+	confirmSafeguard = terminal.NewTypedConfirmForm
+	// bannerOutput is where the safeguard banner is written, replaced in tests
+	bannerOutput io.Writer = os.Stderr
+)
+
+// UseSafeguards requires a typed confirmation before handing out credentials of matching accounts and roles
+func (c *Client) UseSafeguards(safeguards []file.Safeguard) {
+	c.safeguards = safeguards
+}
+
+// guardSelection confirms the selection of a safeguarded account and role, exiting when it is not confirmed
+// Static keys are refused for the default profile and when credentials are capped, as they outlive the cap.
+// confirm is the account typed ahead with --confirm, used when not interactive.
+func guardSelection(ctx context.Context, s *Client, accessToken, accountID, roleName, profile string, keys bool, confirm string) {
+	logger := zerolog.Ctx(ctx)
+	if len(s.safeguards) == 0 {
+		return
+	}
+
+	names := s.accountNames(ctx, accessToken, accountID)
+	matched := s.matchSafeguards(ctx, names, accountID, roleName)
+	if len(matched) == 0 {
+		return
+	}
+	maxDuration := safeguardMaxDuration(matched)
+
+	if keys && profile == defaultProfile {
+		logger.Fatal().Msgf("Refusing to write keys of safeguarded account %s to the %s profile, set --profile", accountID, defaultProfile)
+	}
+	if keys && maxDuration > 0 {
+		logger.Fatal().Msgf("Refusing to write keys of safeguarded account %s, keys are kept past the max-duration of %s", accountID, maxDuration)
+	}
+
+	terminal.Banner(bannerOutput,
+		"SAFEGUARDED ACCOUNT",
+		fmt.Sprintf("account %s", s.describeAccount(accountID)),
+		fmt.Sprintf("role    %s", roleName),
+	)
+
+	if len(confirm) > 0 {
+		if !terminal.TypedConfirmation(confirm, names) {
+			logger.Fatal().Msgf("--confirm %q does not match account %s", confirm, accountID)
+		}
+		return
+	}
+	if !isInteractive() {
+		logger.Fatal().Msgf("Account %s is safeguarded, set --confirm %s to continue without a terminal", accountID, names[0])
+	}
+	if err := confirmSafeguard(fmt.Sprintf("Type %s to continue", names[0]), names); err != nil {
+		logger.Fatal().Msgf("Selection was not confirmed: %v", err)
+	}
+}
+
+// safeguardedDuration returns how long credentials of the account and role are used, zero when not capped
+// Only the catalog is used to look up the account name, as the credential process does not list accounts.
+// When the name is not cached, safeguards on account names could match, so the strictest of them applies.
+func (c *Client) safeguardedDuration(ctx context.Context, accountID, roleName string) time.Duration {
+	if len(c.safeguards) == 0 {
+		return 0
+	}
+	names, named := c.lookupAccountNames(ctx, "", accountID)
+	matched := c.matchSafeguards(ctx, names, accountID, roleName)
+	if !named {
+		environment := c.labels.Metadata[accountID].Environment
+		for _, safeguard := range c.safeguards {
+			if accountIDGlob.MatchString(safeguard.Account) {
+				continue
+			}
+			if patternMatches(ctx, safeguard.Environment, environment) && patternMatches(ctx, safeguard.Role, roleName) {
+				matched = append(matched, safeguard)
+			}
+		}
+	}
+	return safeguardMaxDuration(matched)
+}
+
+// accountNames returns the account name when known, the account id and the aliases from config
+// The name is looked up in the catalog, or listed when the catalog does not have the account and a token is set.
+func (c *Client) accountNames(ctx context.Context, accessToken, accountID string) []string {
+	names, _ := c.lookupAccountNames(ctx, accessToken, accountID)
+	return names
+}
+
+// lookupAccountNames returns the names of accountNames and whether the account name was found
+func (c *Client) lookupAccountNames(ctx context.Context, accessToken, accountID string) ([]string, bool) {
+	cached, _ := c.catalogAccounts(ctx)
+	account, ok := findAccount(cached, accountID)
+	if !ok && len(accessToken) > 0 {
+		if accounts, err := c.matchableAccounts(ctx, accessToken); err == nil {
+			account, ok = findAccount(accounts, accountID)
+		}
+	}
+
+	var names []string
+	named := ok && len(aws.ToString(account.AccountName)) > 0
+	if named {
+		names = append(names, aws.ToString(account.AccountName))
+	}
+	names = append(names, accountID)
+	return append(names, c.labels.Metadata[accountID].Aliases...), named
+}
+
+// matchSafeguards returns the safeguards matching the account names, environment and role
+func (c *Client) matchSafeguards(ctx context.Context, names []string, accountID, roleName string) []file.Safeguard {
+	environment := c.labels.Metadata[accountID].Environment
+	var matched []file.Safeguard
+	for _, safeguard := range c.safeguards {
+		if safeguardMatches(ctx, safeguard, names, environment, roleName) {
+			matched = append(matched, safeguard)
+		}
+	}
+	return matched
+}
+
+// safeguardMatches returns true when every pattern set matches, a safeguard without patterns never matches
+// An invalid pattern matches anything, so a typo does not silently turn the safeguard off.
+func safeguardMatches(ctx context.Context, safeguard file.Safeguard, names []string, environment, roleName string) bool {
+	if len(safeguard.Environment) == 0 && len(safeguard.Account) == 0 && len(safeguard.Role) == 0 {
+		return false
+	}
+	return patternMatches(ctx, safeguard.Environment, environment) &&
+		patternMatches(ctx, safeguard.Account, names...) &&
+		patternMatches(ctx, safeguard.Role, roleName)
+}
+
+// patternMatches returns true when the pattern is empty or matches any of the values
+// An invalid pattern matches anything, as it guards against handing out credentials.
+func patternMatches(ctx context.Context, pattern string, values ...string) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	match, err := patternMatcher(pattern)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Msgf("Matching anything with %v", err)
+		return true
+	}
+	for _, value := range values {
+		if match(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// safeguardMaxDuration returns the shortest max duration of the safeguards, zero when none is set
+func safeguardMaxDuration(safeguards []file.Safeguard) time.Duration {
+	var shortest time.Duration
+	for _, safeguard := range safeguards {
+		if safeguard.MaxDuration > 0 && (shortest == 0 || safeguard.MaxDuration < shortest) {
+			shortest = safeguard.MaxDuration
+		}
+	}
+	return shortest
+}
+
+// capExpiration returns the expiration capped to the max duration from now, unchanged when not capped
+func capExpiration(expiration time.Time, maxDuration time.Duration) time.Time {
+	if maxDuration <= 0 {
+		return expiration
+	}
+	if capped := time.Now().Add(maxDuration); capped.Before(expiration) {
+		return capped
+	}
+	return expiration
+}
@@ -0,0 +1,147 @@
+package amazon
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"ssoctx/internal/file"
+)
+
+func TestSafeguardMatches(t *testing.T) {
+	names := []string{"prod-app", "111111111111", "payments"}
+	tests := []struct {
+		name      string
+		safeguard file.Safeguard
+		role      string
+		want      bool
+	}{
+		{"no patterns", file.Safeguard{}, "Admin", false},
+		{"environment", file.Safeguard{Environment: "prod"}, "ReadOnly", true},
+		{"other environment", file.Safeguard{Environment: "dev"}, "ReadOnly", false},
+		{"account alias", file.Safeguard{Account: "pay*"}, "ReadOnly", true},
+		{"account name regex", file.Safeguard{Account: "/^prod-/"}, "ReadOnly", true},
+		{"role", file.Safeguard{Role: "*admin*"}, "AdministratorAccess", true},
+		{"every pattern has to match", file.Safeguard{Environment: "prod", Role: "*Admin*"}, "ReadOnly", false},
+		{"invalid pattern matches", file.Safeguard{Role: "[Admin"}, "ReadOnly", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := safeguardMatches(zerologTestingContext, tt.safeguard, names, "prod", tt.role); got != tt.want {
+				t.Errorf("safeguardMatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSafeguardMaxDuration(t *testing.T) {
+	safeguards := []file.Safeguard{{Environment: "prod"}, {Role: "*Admin*", MaxDuration: time.Hour}, {MaxDuration: 15 * time.Minute}}
+	if got := safeguardMaxDuration(safeguards); got != 15*time.Minute {
+		t.Errorf("safeguardMaxDuration() = %v, want %v", got, 15*time.Minute)
+	}
+	if got := safeguardMaxDuration(safeguards[:1]); got != 0 {
+		t.Errorf("safeguardMaxDuration() = %v, want 0", got)
+	}
+
+	expiration := time.Now().Add(time.Hour)
+	if got := capExpiration(expiration, 0); !got.Equal(expiration) {
+		t.Errorf("capExpiration() = %v, want %v", got, expiration)
+	}
+	if got := capExpiration(expiration, 2*time.Hour); !got.Equal(expiration) {
+		t.Errorf("capExpiration() = %v, want %v", got, expiration)
+	}
+	if got := capExpiration(expiration, 15*time.Minute); !got.Before(time.Now().Add(16 * time.Minute)) {
+		t.Errorf("capExpiration() = %v, want within 15m", got)
+	}
+}
+
+func TestGuardSelection(t *testing.T) {
+	tests := []struct {
+		name        string
+		safeguards  []file.Safeguard
+		role        string
+		confirm     string
+		wantBanner  bool
+		wantPrompts int
+	}{
+		{"not safeguarded", []file.Safeguard{{Environment: "dev"}}, "Admin", "", false, 0},
+		{"prompts for the account", []file.Safeguard{{Environment: "prod"}}, "Admin", "", true, 1},
+		{"confirmed ahead", []file.Safeguard{{Role: "Admin"}}, "Admin", "prod-app", true, 0},
+		{"confirmed ahead by alias", []file.Safeguard{{Role: "Admin"}}, "Admin", "payments", true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempCredentialsCache(t)
+			calls := 0
+			c := catalogClient(testAccounts(), false, &calls)
+			seedCatalog(c, 0)
+			c.UseAccountMetadata(map[string]file.AccountMetadata{"111111111111": {Aliases: []string{"payments"}, Environment: "prod"}}, false)
+			c.UseSafeguards(tt.safeguards)
+
+			var banner bytes.Buffer
+			originalOutput, originalConfirm, originalInteractive := bannerOutput, confirmSafeguard, isInteractive
+			defer func() {
+				bannerOutput, confirmSafeguard, isInteractive = originalOutput, originalConfirm, originalInteractive
+			}()
+			bannerOutput = &banner
+			isInteractive = func() bool { return true }
+			prompts := 0
+			var expected []string
+			confirmSafeguard = func(title string, names []string) error {
+				prompts++
+				expected = names
+				return nil
+			}
+
+			guardSelection(zerologTestingContext, c, "token", "111111111111", tt.role, "work", false, tt.confirm)
+
+			if got := strings.Contains(banner.String(), "SAFEGUARDED ACCOUNT"); got != tt.wantBanner {
+				t.Errorf("banner = %q, want banner %v", banner.String(), tt.wantBanner)
+			}
+			if prompts != tt.wantPrompts {
+				t.Fatalf("prompted %d times, want %d", prompts, tt.wantPrompts)
+			}
+			if prompts > 0 && strings.Join(expected, ",") != "prod-app,111111111111,payments" {
+				t.Errorf("confirmation expected %v, want account name, id and aliases", expected)
+			}
+		})
+	}
+}
+
+func TestSafeguardedDuration(t *testing.T) {
+	useTempCredentialsCache(t)
+	calls := 0
+	c := catalogClient(testAccounts(), false, &calls)
+	seedCatalog(c, 0)
+	c.UseSafeguards([]file.Safeguard{
+		{Account: "prod-*", MaxDuration: time.Hour},
+		{Account: "2222*", MaxDuration: 30 * time.Minute},
+		{Account: "prod-*", Role: "Admin", MaxDuration: 15 * time.Minute},
+	})
+
+	tests := []struct {
+		name      string
+		accountID string
+		role      string
+		want      time.Duration
+	}{
+		{"named account", "111111111111", "ReadOnly", time.Hour},
+		{"named account and role", "111111111111", "Admin", 15 * time.Minute},
+		// the catalog only has the first account, so names of the others are unknown
+		{"unknown name gets name caps", "333333333333", "ReadOnly", time.Hour},
+		{"unknown name gets the strictest cap", "333333333333", "Admin", 15 * time.Minute},
+		{"account id pattern still matches by id", "222222222222", "ReadOnly", 30 * time.Minute},
+		{"account id pattern not matching", "444444444444", "ReadOnly", time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.safeguardedDuration(zerologTestingContext, tt.accountID, tt.role); got != tt.want {
+				t.Errorf("safeguardedDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if calls != 0 {
+		t.Errorf("safeguardedDuration() listed accounts %d times, want 0", calls)
+	}
+}
@@ -2,9 +2,12 @@ package amazon
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
 	"github.com/aws/aws-sdk-go-v2/service/sso/types"
 	"github.com/rs/zerolog"
 
@@ -12,21 +15,36 @@ import (
 	"ssoctx/internal/terminal"
 )
 
+var (
+	// selectAccountStream is the account picker, replaced in tests
+	selectAccountStream terminal.StreamSelectorFunc[types.AccountInfo] = terminal.NewStreamSelectForm[types.AccountInfo]
+	// selectAccountRole is the combined account and role picker, replaced in tests
+	selectAccountRole terminal.StreamSelectorFunc[terminal.AccountRole] = terminal.NewStreamSelectForm[terminal.AccountRole]
+)
+
 // SelectFlagInputs contains all needed inputs for login to select account and role
 type SelectFlagInputs struct {
-	Clean      bool
-	AccountID  string
-	RoleName   string
-	StartURL   string
-	Region     string
-	Profile    string
-	Keys       bool
-	PrintCreds bool
+	Clean           bool
+	AccountID       string
+	RoleName        string
+	StartURL        string
+	Region          string
+	Profile         string
+	Keys            bool
+	PrintCreds      bool
+	Previous        bool            // switch back to the previous account and role
+	Account         string          // account name, alias, id or pattern resolved to AccountID
+	Role            string          // role name pattern resolved to RoleName
+	Chain           *file.RoleChain // role chain to assume on top of the SSO role
+	Confirm         string          // account name, id or alias typed ahead for safeguarded accounts
+	Multi           bool            // select several accounts and roles, writing a profile each
+	ProfileTemplate string          // names the profiles written with Multi, defaults to DefaultProfileTemplate
 }
 
 // Select is the primary subcommand used to interactively select account and role
 func Select(ctx context.Context, o *OIDCClientAPI, s *Client, inputs SelectFlagInputs) {
 	logger := zerolog.Ctx(ctx)
+	enforceStartURL(ctx, s, inputs.StartURL)
 	destination := clientInfoFileDestination(inputs.StartURL)
 
 	if inputs.Clean {
@@ -40,42 +58,57 @@ func Select(ctx context.Context, o *OIDCClientAPI, s *Client, inputs SelectFlagI
 	if err != nil {
 		logger.Fatal().Msgf("Encountered error in processClientInformation: %v", err)
 	}
-	writeStructToFile(ctx, &clientInformation, destination)
-
-	var accountInfo *types.AccountInfo
-	if len(inputs.AccountID) == 0 {
-		accountsOutput, laErr := s.listAccounts(ctx, clientInformation.AccessToken)
-		if laErr != nil {
-			logger.Fatal().Msgf("Encountered error in listAccounts: %v", laErr)
-		}
-		accountInfo, err = terminal.SelectAccount(accountsOutput, terminal.NewSelectForm)
-		if err != nil {
-			logger.Fatal().Msgf("Encountered error in selectAccount: %v", err)
-		}
-		inputs.AccountID = *accountInfo.AccountId
+	writeSecretToFile(ctx, &clientInformation, destination)
+	if len(inputs.StartURL) == 0 {
+		inputs.StartURL = clientInformation.StartURL
 	}
 
-	var roleInfo *types.RoleInfo
-	if len(inputs.RoleName) == 0 {
-		listRolesOutput, larErr := s.listAvailableRoles(ctx, inputs.AccountID, clientInformation.AccessToken)
-		if larErr != nil {
-			logger.Fatal().Msgf("Encountered error in listAvailableRoles: %v", larErr)
-		}
-		roleInfo, err = terminal.SelectRole(listRolesOutput, terminal.NewSelectForm)
-		if err != nil {
-			logger.Fatal().Msgf("Encountered error in selectRole: %v", err)
+	if inputs.Multi {
+		selectMulti(ctx, s, clientInformation.AccessToken, inputs)
+		return
+	}
+	if inputs.Previous {
+		previous, ok := s.previousSelection(ctx)
+		if !ok {
+			logger.Fatal().Msg("No previous account and role selected")
 		}
-		inputs.RoleName = *roleInfo.RoleName
+		inputs.AccountID = previous.AccountID
+		inputs.RoleName = previous.RoleName
 	}
-
-	if len(inputs.StartURL) == 0 {
-		inputs.StartURL = clientInformation.StartURL
+	if inputs.Chain != nil {
+		inputs.AccountID = inputs.Chain.AccountID
+		inputs.RoleName = inputs.Chain.RoleName
+	}
+	inputs.AccountID, inputs.RoleName = resolveAccountAndRole(ctx, s, clientInformation.AccessToken, inputs.Account, inputs.Role, inputs.AccountID, inputs.RoleName)
+	checkAccountID(ctx, s, clientInformation.AccessToken, inputs.AccountID)
+	inputs.AccountID, inputs.RoleName = selectAccountAndRole(ctx, s, clientInformation.AccessToken, inputs.AccountID, inputs.RoleName)
+	guardSelection(ctx, s, clientInformation.AccessToken, inputs.AccountID, inputs.RoleName, inputs.Profile, inputs.Keys && !inputs.PrintCreds, inputs.Confirm)
+	switch {
+	case inputs.PrintCreds:
+		enforcePolicy(ctx, s, clientInformation.AccessToken, inputs.AccountID, inputs.RoleName, "", file.OutputPrint)
+	case inputs.Keys:
+		enforcePolicy(ctx, s, clientInformation.AccessToken, inputs.AccountID, inputs.RoleName, inputs.Profile, file.OutputKeys)
+	default:
+		enforcePolicy(ctx, s, clientInformation.AccessToken, inputs.AccountID, inputs.RoleName, inputs.Profile, file.OutputProcess)
 	}
 
-	roleCredentials, err := s.getRolesCredentials(ctx, inputs.AccountID, inputs.RoleName, clientInformation.AccessToken)
+	ssoCredentials := func() (*sso.GetRoleCredentialsOutput, error) {
+		roleCredentials, err := s.getRolesCredentials(ctx, inputs.AccountID, inputs.RoleName, clientInformation.AccessToken)
+		return roleCredentials, checkRoleCredentialsError(ctx, s, clientInformation.AccessToken, inputs.AccountID, inputs.RoleName, err)
+	}
+	var roleCredentials *sso.GetRoleCredentialsOutput
+	if inputs.Chain != nil {
+		roleCredentials, err = resolveChainCredentials(ctx, inputs.Chain, inputs.StartURL, inputs.Region, s.safeguardedDuration(ctx, inputs.AccountID, inputs.RoleName), ssoCredentials)
+	} else {
+		roleCredentials, err = ssoCredentials()
+	}
 	if err != nil {
 		logger.Fatal().Msgf("Encountered error attempting to getRoleCredentials: %v", err)
 	}
+	logger.Info().Msgf("Selected account %s with role %s", s.describeAccount(inputs.AccountID), inputs.RoleName)
+	if inputs.Chain == nil {
+		s.recordSelection(ctx, inputs.AccountID, inputs.RoleName)
+	}
 
 	// does not write to file because folks just want environment variables
 	if inputs.PrintCreds {
@@ -92,6 +125,189 @@ func Select(ctx context.Context, o *OIDCClientAPI, s *Client, inputs SelectFlagI
 		)
 	} else {
 		template := getCredentialProcess(inputs.AccountID, inputs.RoleName, inputs.Region, inputs.StartURL)
+		if inputs.Chain != nil {
+			template = getChainCredentialProcess(inputs.Chain.Name, inputs.Region, inputs.StartURL)
+		}
 		writeAWSCredentialsFile(ctx, &template, inputs.Profile)
 	}
 }
+
+// selectAccountAndRole interactively selects whichever of account id and role name is missing
+// A missing role is chosen from the role preference without prompting when one is available.
+func selectAccountAndRole(ctx context.Context, s *Client, accessToken, accountID, roleName string) (string, string) {
+	logger := zerolog.Ctx(ctx)
+
+	// with role preferences only the account is picked, so the preferred role can be chosen
+	if len(accountID) == 0 && len(roleName) == 0 && !s.prefersRoles() {
+		accountID, roleName = pickAccountAndRole(ctx, s)
+	}
+
+	if len(accountID) == 0 {
+		accountInfo, err := pickAccount(ctx, s, accessToken)
+		if err != nil {
+			logger.Fatal().Msgf("Encountered error in selectAccount: %v", err)
+		}
+		accountID = *accountInfo.AccountId
+	}
+
+	if len(roleName) == 0 {
+		listRolesOutput, err := s.catalogRoles(ctx, accountID, accessToken)
+		if err != nil {
+			logger.Fatal().Msgf("Encountered error in listAvailableRoles: %v", err)
+		}
+		if preferred, ok := s.preferredRole(ctx, accountID, listRolesOutput.RoleList); ok {
+			logger.Info().Msgf("Using preferred role %s, set --pick-role to choose another", preferred)
+			return accountID, preferred
+		}
+		roleInfo, err := terminal.SelectRole(listRolesOutput, s.preferredRoles(ctx, accountID), pickerSelector(s, terminal.NewSelectForm[types.RoleInfo]))
+		if err != nil {
+			logger.Fatal().Msgf("Encountered error in selectRole: %v", err)
+		}
+		roleName = *roleInfo.RoleName
+	}
+
+	return accountID, roleName
+}
+
+// pickAccountAndRole selects an account and role pair in one fuzzy picker when roles are cached
+// Accounts without cached roles are listed with an empty role, so their role is selected afterwards.
+// Nothing is selected when no roles are cached yet.
+func pickAccountAndRole(ctx context.Context, s *Client) (string, string) {
+	logger := zerolog.Ctx(ctx)
+
+	pairs := s.catalogAccountRoles(ctx)
+	if len(pairs) == 0 {
+		return "", ""
+	}
+	pair, err := terminal.SelectAccountRole(pairs, s.labels, s.preferredAccountRoles(ctx), pickerStreamSelector(s, selectAccountRole))
+	if err != nil {
+		logger.Fatal().Msgf("Encountered error in selectAccountRole: %v", err)
+	}
+	return aws.ToString(pair.Account.AccountId), aws.ToString(pair.Role.RoleName)
+}
+
+// pickAccount lists accounts into the account picker page by page
+// Favourite and recently used accounts are pinned at the top.
+// The picker is usable before the last page and shows throttled retries while loading.
+// Cached accounts are shown immediately and only listed again once older than the catalog ttl.
+// Listing continues after an account is picked and is waited for up to catalogRefreshTimeout,
+// so the catalog is refreshed before the process exits.
+func pickAccount(ctx context.Context, s *Client, accessToken string) (types.AccountInfo, error) {
+	picked := make(chan struct{})
+	listing := make(chan struct{})
+
+	pages := make(chan terminal.OptionPage[types.AccountInfo])
+	send := func(page terminal.OptionPage[types.AccountInfo]) {
+		select {
+		case pages <- page:
+		case <-picked:
+		}
+	}
+
+	cached, fresh := s.catalogAccounts(ctx)
+	go func() {
+		defer close(listing)
+		defer close(pages)
+		if pinned := s.pinnedAccounts(ctx, cached); len(pinned) > 0 {
+			page := terminal.AccountPage(pinned, s.labels)
+			page.Pinned = true
+			send(page)
+		}
+		if len(cached) > 0 && !s.refreshingCatalog() {
+			send(terminal.AccountPage(cached, s.labels))
+			if fresh {
+				return
+			}
+		}
+
+		onThrottle := func(operation string, attempt int, delay time.Duration) {
+			send(terminal.OptionPage[types.AccountInfo]{
+				Status: fmt.Sprintf("%s throttled, retry %d in %s", operation, attempt, delay.Round(time.Millisecond)),
+			})
+		}
+		var listed []types.AccountInfo
+		err := s.listAccountPages(ctx, accessToken, onThrottle, func(accounts []types.AccountInfo) {
+			listed = append(listed, accounts...)
+			send(terminal.AccountPage(accounts, s.labels))
+		})
+		if err != nil {
+			page := terminal.OptionPage[types.AccountInfo]{}
+			if s.refreshingCatalog() {
+				// fall back to the cache that was not shown
+				page = terminal.AccountPage(cached, s.labels)
+			}
+			page.Err = err
+			send(page)
+			return
+		}
+		s.saveCatalogAccounts(ctx, listed)
+	}()
+
+	account, err := pickerStreamSelector(s, selectAccountStream)(pages, "Select your account")
+	close(picked)
+	waitForListing(ctx, listing)
+	return account, err
+}
+
+// waitForListing waits for the account listing to finish, up to catalogRefreshTimeout
+func waitForListing(ctx context.Context, listing <-chan struct{}) {
+	select {
+	case <-listing:
+	case <-time.After(catalogRefreshTimeout):
+		zerolog.Ctx(ctx).Debug().Msgf("Account listing did not finish within %s, the catalog is not refreshed", catalogRefreshTimeout)
+	}
+}
+
+// pickerSelector returns the external picker of the client or the built in picker
+func pickerSelector[T comparable](s *Client, builtIn terminal.SelectorFunc[T]) terminal.SelectorFunc[T] {
+	if len(s.picker) > 0 {
+		return terminal.NewExternalSelector[T](s.picker)
+	}
+	return builtIn
+}
+
+// pickerStreamSelector returns the external picker of the client or the built in stream picker
+func pickerStreamSelector[T comparable](s *Client, builtIn terminal.StreamSelectorFunc[T]) terminal.StreamSelectorFunc[T] {
+	if len(s.picker) > 0 {
+		return terminal.NewExternalStreamSelector[T](s.picker)
+	}
+	return builtIn
+}
+
+// resolveRoleCredentials returns role credentials for the account and role
+// When both are set, cached credentials are used while valid without logging in.
+// Otherwise the missing account or role is selected interactively after logging in.
+// The policy is enforced for the output mode before credentials are fetched or cached.
+func resolveRoleCredentials(ctx context.Context, o *OIDCClientAPI, s *Client, accountID, roleName, startURL, output string) (string, string, *sso.GetRoleCredentialsOutput) {
+	logger := zerolog.Ctx(ctx)
+
+	enforceStartURL(ctx, s, startURL)
+	checkAccountID(ctx, s, "", accountID)
+	if len(accountID) > 0 && len(roleName) > 0 {
+		enforcePolicy(ctx, s, "", accountID, roleName, "", output)
+		roleCredentials, err := cachedRoleCredentials(ctx, o, s, accountID, roleName, startURL)
+		if err != nil {
+			if clientInformation, readErr := readClientInformation(ctx, clientInfoFileDestination(startURL)); readErr == nil {
+				err = checkRoleCredentialsError(ctx, s, clientInformation.AccessToken, accountID, roleName, err)
+			}
+			logger.Fatal().Msgf("Encountered error attempting to getRoleCredentials: %v", err)
+		}
+		return accountID, roleName, roleCredentials
+	}
+
+	destination := clientInfoFileDestination(startURL)
+	clientInformation, err := o.processClientInformation(ctx, destination)
+	if err != nil {
+		logger.Fatal().Msgf("Encountered error in processClientInformation: %v", err)
+	}
+	writeSecretToFile(ctx, &clientInformation, destination)
+
+	accountID, roleName = selectAccountAndRole(ctx, s, clientInformation.AccessToken, accountID, roleName)
+	enforcePolicy(ctx, s, clientInformation.AccessToken, accountID, roleName, "", output)
+	roleCredentials, err := s.getRolesCredentials(ctx, accountID, roleName, clientInformation.AccessToken)
+	if err = checkRoleCredentialsError(ctx, s, clientInformation.AccessToken, accountID, roleName, err); err != nil {
+		logger.Fatal().Msgf("Encountered error attempting to getRoleCredentials: %v", err)
+	}
+	writeCachedCredentials(ctx, roleCacheKey(startURL, accountID, roleName), roleCredentials, s.safeguardedDuration(ctx, accountID, roleName))
+	return accountID, roleName, roleCredentials
+}
@@ -0,0 +1,186 @@
+package amazon
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+
+	"ssoctx/internal/terminal"
+)
+
+// pagedAccountsClient returns a page per account, failing on the page after failAfter when set
+func pagedAccountsClient(failAfter int) *Client {
+	accounts := testAccounts()
+	return NewSSOClient(&mockSSOClient{
+		ListAccountsAPI: func(ctx context.Context, params *sso.ListAccountsInput, optFns ...func(*sso.Options)) (*sso.ListAccountsOutput, error) {
+			index := 0
+			if params.NextToken != nil {
+				index, _ = strconv.Atoi(*params.NextToken)
+			}
+			if failAfter > 0 && index >= failAfter {
+				return nil, errors.New("access denied")
+			}
+			output := &sso.ListAccountsOutput{AccountList: accounts[index : index+1]}
+			if index+1 < len(accounts) {
+				output.NextToken = aws.String(strconv.Itoa(index + 1))
+			}
+			return output, nil
+		},
+	})
+}
+
+func TestClient_ListAccountsPages(t *testing.T) {
+	output, err := pagedAccountsClient(0).listAccounts(zerologTestingContext, "token")
+	if err != nil {
+		t.Fatalf("listAccounts() error = %v", err)
+	}
+	if len(output.AccountList) != 3 || *output.AccountList[2].AccountId != "333333333333" {
+		t.Errorf("listAccounts() = %v, want every page", output.AccountList)
+	}
+}
+
+func TestPickAccount(t *testing.T) {
+	tests := []struct {
+		name      string
+		failAfter int
+		wantPages int
+		wantErr   bool
+	}{
+		{"every page", 0, 3, false},
+		{"listing error", 2, 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := selectAccountStream
+			defer func() { selectAccountStream = original }()
+
+			var (
+				options int
+				pageErr error
+			)
+			selectAccountStream = func(pages <-chan terminal.OptionPage[types.AccountInfo], title string) (types.AccountInfo, error) {
+				var last types.AccountInfo
+				for page := range pages {
+					options += len(page.Options)
+					if page.Err != nil {
+						pageErr = page.Err
+					}
+					if len(page.Options) > 0 {
+						last = page.Options[len(page.Options)-1].Value
+					}
+				}
+				return last, nil
+			}
+
+			account, err := pickAccount(zerologTestingContext, pagedAccountsClient(tt.failAfter), "token")
+			if err != nil {
+				t.Fatalf("pickAccount() error = %v", err)
+			}
+			if options != tt.wantPages || (pageErr != nil) != tt.wantErr {
+				t.Errorf("pickAccount() streamed %d options, err %v, want %d, wantErr %v", options, pageErr, tt.wantPages, tt.wantErr)
+			}
+			if *account.AccountId != *testAccounts()[tt.wantPages-1].AccountId {
+				t.Errorf("pickAccount() = %v, want the last streamed account", *account.AccountId)
+			}
+		})
+	}
+}
+
+func TestPickAccountAndRole(t *testing.T) {
+	tests := []struct {
+		name        string
+		seed        bool
+		stale       bool // accounts older than the ttl
+		refresh     bool
+		history     [][2]string // account id and role name, oldest first
+		wantKeys    []string
+		wantAccount string
+		wantRole    string
+	}{
+		{
+			name: "no cached roles",
+		},
+		{
+			name:    "refresh",
+			seed:    true,
+			refresh: true,
+		},
+		{
+			name:  "stale accounts",
+			seed:  true,
+			stale: true,
+		},
+		{
+			name: "cached roles",
+			seed: true,
+			wantKeys: []string{
+				"dev-app                        222222222222 /  / (choose role)",
+				"prod-app                       111111111111 /  / Cached",
+				"prod-data                      333333333333 /  / (choose role)",
+			},
+			wantAccount: "222222222222",
+		},
+		{
+			name:    "recent first",
+			seed:    true,
+			history: [][2]string{{"111111111111", "Cached"}},
+			wantKeys: []string{
+				"prod-app                       111111111111 /  / Cached",
+				"dev-app                        222222222222 /  / (choose role)",
+				"prod-data                      333333333333 /  / (choose role)",
+			},
+			wantAccount: "111111111111",
+			wantRole:    "Cached",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempCredentialsCache(t)
+			original := selectAccountRole
+			defer func() { selectAccountRole = original }()
+
+			calls := 0
+			c := catalogClient(testAccounts(), tt.refresh, &calls)
+			c.UseHistory(testCatalogURL, nil)
+			if tt.seed {
+				seedCatalog(c, time.Minute)
+				c.saveCatalogAccounts(zerologTestingContext, testAccounts())
+			}
+			if tt.stale {
+				c.updateCatalog(zerologTestingContext, func(cat *accountCatalog) {
+					cat.AccountsUpdatedAt = time.Now().Add(-2 * time.Hour)
+				})
+			}
+			for _, selection := range tt.history {
+				c.recordSelection(zerologTestingContext, selection[0], selection[1])
+			}
+
+			var keys []string
+			selectAccountRole = func(pages <-chan terminal.OptionPage[terminal.AccountRole], title string) (terminal.AccountRole, error) {
+				page := <-pages
+				for _, option := range page.Options {
+					keys = append(keys, option.Key)
+				}
+				return page.Options[0].Value, nil
+			}
+
+			accountID, roleName := pickAccountAndRole(zerologTestingContext, c)
+			if !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("options = %q, want %q", keys, tt.wantKeys)
+			}
+			if accountID != tt.wantAccount || roleName != tt.wantRole {
+				t.Errorf("pickAccountAndRole() = %s %s, want %s %s", accountID, roleName, tt.wantAccount, tt.wantRole)
+			}
+			if calls != 0 {
+				t.Errorf("listed %d times, want cached only", calls)
+			}
+		})
+	}
+}
@@ -2,9 +2,14 @@ package amazon
 
 import (
 	"context"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
 	"github.com/rs/zerolog"
+
+	"ssoctx/internal/file"
+	"ssoctx/internal/terminal"
 )
 
 // SSOClient is used to abstract the client calls for mock testing
@@ -16,7 +21,15 @@ type SSOClient interface {
 
 // Client contains everything needed to make SSO api calls
 type Client struct {
-	client SSOClient
+	client     SSOClient
+	catalog    *catalog               // cached account and role catalog, nil unless UseCatalog is called
+	history    *history               // account and role selection history, nil unless UseHistory is called
+	picker     string                 // external picker command, the built in pickers are used when empty
+	labels     terminal.AccountLabels // account metadata from config shown in the pickers
+	safeguards []file.Safeguard       // accounts and roles requiring confirmation, see UseSafeguards
+	policy     *file.Policy           // restricts how credentials are handed out, nil unless UsePolicy is called
+	preference rolePreference         // roles chosen without prompting, see UseRolePreference
+	reauth     *reauthentication      // nil unless UseReauthentication is called
 }
 
 // NewSSOClient implements the interface
@@ -24,39 +37,104 @@ func NewSSOClient(s SSOClient) *Client {
 	return &Client{client: s}
 }
 
-// listAvailableRoles is used to return a ListAccountRolesOutput
+// UsePicker sets the external picker command used instead of the built in pickers
+func (c *Client) UsePicker(command string) {
+	c.picker = command
+}
+
+// listAvailableRoles is used to return a ListAccountRolesOutput with the roles of every page
 func (c *Client) listAvailableRoles(ctx context.Context, accountID, accessToken string) (*sso.ListAccountRolesOutput, error) {
 	logger := zerolog.Ctx(ctx)
+	accessToken = c.currentToken(accessToken)
+	// the paginator reads the token through the pointer, so a renewed token is used by the next page
 	lari := &sso.ListAccountRolesInput{AccountId: &accountID, AccessToken: &accessToken}
-	roles, err := c.client.ListAccountRoles(ctx, lari)
-	if err != nil {
-		// pass through for debug
-		_ = GetAWSErrorCode(ctx, err)
-		return &sso.ListAccountRolesOutput{}, err
+	paginator := sso.NewListAccountRolesPaginator(c.client, lari)
+
+	roles := &sso.ListAccountRolesOutput{}
+	renewed := false
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx, withThrottleRetries("ListAccountRoles", logThrottle(ctx)))
+		if err != nil && !renewed {
+			if accessToken, err = c.renewToken(ctx, accessToken, err); err == nil {
+				renewed = true
+				continue
+			}
+		}
+		if err != nil {
+			// pass through for debug
+			_ = GetAWSErrorCode(ctx, err)
+			return &sso.ListAccountRolesOutput{}, err
+		}
+		roles.RoleList = append(roles.RoleList, page.RoleList...)
 	}
 	logger.Debug().Msgf("ListAccountRoles returned %d available roles", len(roles.RoleList))
 
 	return roles, nil
 }
 
-// listAccounts is used to return the ListAccountsOutput
+// listAccounts is used to return the ListAccountsOutput with the accounts of every page
 func (c *Client) listAccounts(ctx context.Context, accessToken string) (*sso.ListAccountsOutput, error) {
-	var maxSize int32 = 500
-	lai := sso.ListAccountsInput{AccessToken: &accessToken, MaxResults: &maxSize}
-	accounts, err := c.client.ListAccounts(ctx, &lai)
+	accounts := &sso.ListAccountsOutput{}
+	err := c.listAccountPages(ctx, accessToken, logThrottle(ctx), func(page []types.AccountInfo) {
+		accounts.AccountList = append(accounts.AccountList, page...)
+	})
 	if err != nil {
-		// pass through for debug
-		_ = GetAWSErrorCode(ctx, err)
+		if cached, _ := c.catalogAccounts(ctx); len(cached) > 0 && c.cacheFallback() {
+			zerolog.Ctx(ctx).Warn().Msgf("Using %d cached accounts, listing failed: %v", len(cached), err)
+			return &sso.ListAccountsOutput{AccountList: cached}, nil
+		}
 		return &sso.ListAccountsOutput{}, err
 	}
+	c.saveCatalogAccounts(ctx, accounts.AccountList)
 
 	return accounts, nil
 }
 
+// listAccountPages calls onPage with the accounts of every page as they are listed
+// onThrottle is called before a throttled page is retried
+func (c *Client) listAccountPages(ctx context.Context, accessToken string, onThrottle throttleFunc, onPage func([]types.AccountInfo)) error {
+	var maxSize int32 = 500
+	accessToken = c.currentToken(accessToken)
+	// the paginator reads the token through the pointer, so a renewed token is used by the next page
+	lai := &sso.ListAccountsInput{AccessToken: &accessToken, MaxResults: &maxSize}
+	paginator := sso.NewListAccountsPaginator(c.client, lai)
+	renewed := false
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx, withThrottleRetries("ListAccounts", onThrottle))
+		if err != nil && !renewed {
+			if accessToken, err = c.renewToken(ctx, accessToken, err); err == nil {
+				renewed = true
+				continue
+			}
+		}
+		if err != nil {
+			// pass through for debug
+			_ = GetAWSErrorCode(ctx, err)
+			return err
+		}
+		onPage(page.AccountList)
+	}
+	return nil
+}
+
+// logThrottle returns a throttleFunc that logs throttled retries
+func logThrottle(ctx context.Context) throttleFunc {
+	logger := zerolog.Ctx(ctx)
+	return func(operation string, attempt int, delay time.Duration) {
+		logger.Warn().Msgf("%s was throttled, retry %d in %s", operation, attempt, delay.Round(time.Millisecond))
+	}
+}
+
 // getRolesCredentials is used to return the GetRoleCredentialsOutput
 func (c *Client) getRolesCredentials(ctx context.Context, accountID, roleName, accessToken string) (*sso.GetRoleCredentialsOutput, error) {
+	accessToken = c.currentToken(accessToken)
 	rci := &sso.GetRoleCredentialsInput{AccountId: &accountID, RoleName: &roleName, AccessToken: &accessToken}
 	roleCredentials, err := c.client.GetRoleCredentials(ctx, rci)
+	if err != nil {
+		if accessToken, err = c.renewToken(ctx, accessToken, err); err == nil {
+			roleCredentials, err = c.client.GetRoleCredentials(ctx, rci)
+		}
+	}
 	if err != nil {
 		// pass through for debug
 		_ = GetAWSErrorCode(ctx, err)
@@ -0,0 +1,161 @@
+package amazon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/rs/zerolog"
+)
+
+// ExitInvalidSelection is the exit code when the account or role is malformed or not accessible
+const ExitInvalidSelection = 3
+
+// maxSuggestions is how many close accounts or roles are suggested
+const maxSuggestions = 3
+
+var (
+	// exit is os.Exit, replaced in tests
+	exit = os.Exit
+
+	accountIDPattern = regexp.MustCompile(`^\d{12}$`)
+)
+
+// invalidSelectionCodes are the error codes of GetRoleCredentials for an account or role that is not accessible
+var invalidSelectionCodes = map[string]bool{
+	"ForbiddenException":        true,
+	"ResourceNotFoundException": true,
+}
+
+// checkAccountID exits with ExitInvalidSelection when the account id is not 12 digits
+// Close accounts are suggested when an access token is available.
+func checkAccountID(ctx context.Context, s *Client, accessToken, accountID string) {
+	if len(accountID) == 0 || accountIDPattern.MatchString(accountID) {
+		return
+	}
+	cause := fmt.Errorf("account id %q is not 12 digits", accountID)
+	if len(accessToken) == 0 {
+		invalidSelection(ctx, cause, nil)
+		return
+	}
+	invalidSelection(ctx, cause, s.suggestSelection(ctx, accessToken, accountID, ""))
+}
+
+// checkRoleCredentialsError exits with ExitInvalidSelection and suggestions when credentials were denied
+// for the account and role, other errors are returned as is to be handled by the caller.
+func checkRoleCredentialsError(ctx context.Context, s *Client, accessToken, accountID, roleName string, err error) error {
+	if err == nil || !invalidSelectionCodes[GetAWSErrorCode(ctx, err)] {
+		return err
+	}
+	cause := fmt.Errorf("no access to role %q in account %s: %w", roleName, accountID, err)
+	invalidSelection(ctx, cause, s.suggestSelection(ctx, accessToken, accountID, roleName))
+	return err
+}
+
+// invalidSelection logs the cause with the suggestions and exits with ExitInvalidSelection
+func invalidSelection(ctx context.Context, cause error, suggestions []string) {
+	logger := zerolog.Ctx(ctx)
+	if len(suggestions) > 0 {
+		logger.Error().Msgf("%v\nDid you mean:\n  %s", cause, strings.Join(suggestions, "\n  "))
+	} else {
+		logger.Error().Msgf("%v", cause)
+	}
+	exit(ExitInvalidSelection)
+}
+
+// suggestSelection returns the accounts closest to the account id or name,
+// or the roles closest to the role name when the account is accessible
+func (c *Client) suggestSelection(ctx context.Context, accessToken, accountID, roleName string) []string {
+	logger := zerolog.Ctx(ctx)
+
+	accounts, err := c.matchableAccounts(ctx, accessToken)
+	if err != nil {
+		logger.Debug().Msgf("Not suggesting accounts, listing failed: %v", err)
+		return nil
+	}
+	if _, ok := findAccount(accounts, accountID); !ok {
+		return closestAccounts(accounts, accountID)
+	}
+	if len(roleName) == 0 {
+		return nil
+	}
+
+	roles, err := c.catalogRoles(ctx, accountID, accessToken)
+	if err != nil {
+		logger.Debug().Msgf("Not suggesting roles, listing failed: %v", err)
+		return nil
+	}
+	return closestRoles(roles.RoleList, accountID, roleName)
+}
+
+// closestAccounts returns the accounts whose id or name is closest to the input
+func closestAccounts(accounts []types.AccountInfo, input string) []string {
+	candidates := map[string]int{}
+	for _, account := range accounts {
+		id, name := aws.ToString(account.AccountId), aws.ToString(account.AccountName)
+		distance := min(levenshtein(input, id), levenshtein(strings.ToLower(input), strings.ToLower(name)))
+		if distance <= suggestionThreshold(input) {
+			candidates[fmt.Sprintf("%s %s", id, name)] = distance
+		}
+	}
+	return closest(candidates)
+}
+
+// closestRoles returns the roles of the account closest to the role name
+func closestRoles(roles []types.RoleInfo, accountID, roleName string) []string {
+	candidates := map[string]int{}
+	for _, role := range roles {
+		name := aws.ToString(role.RoleName)
+		if distance := levenshtein(strings.ToLower(roleName), strings.ToLower(name)); distance <= suggestionThreshold(roleName) {
+			candidates[fmt.Sprintf("%s in account %s", name, accountID)] = distance
+		}
+	}
+	return closest(candidates)
+}
+
+// closest returns up to maxSuggestions candidates by distance, then name
+func closest(candidates map[string]int) []string {
+	names := make([]string, 0, len(candidates))
+	for name := range candidates {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if candidates[names[i]] != candidates[names[j]] {
+			return candidates[names[i]] < candidates[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names[:min(len(names), maxSuggestions)]
+}
+
+// suggestionThreshold is the largest edit distance suggested for the input
+func suggestionThreshold(input string) int {
+	return max(2, len(input)/3)
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
@@ -0,0 +1,134 @@
+package amazon
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/smithy-go"
+)
+
+// useExitCode replaces exit to record the exit code instead of exiting
+func useExitCode(t *testing.T) *int {
+	t.Helper()
+	code := 0
+	original := exit
+	exit = func(c int) { code = c }
+	t.Cleanup(func() { exit = original })
+	return &code
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"ReadOnly", "ReadOnly", 0},
+		{"ReadOnyl", "ReadOnly", 2},
+		{"111111111112", "111111111111", 1},
+		{"kitten", "sitting", 3},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestClosestAccounts(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"mistyped id", "111111111121", []string{"111111111111 prod-app"}},
+		{"mistyped name", "prod-dta", []string{"333333333333 prod-data"}},
+		{"nothing close", "staging", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := closestAccounts(testAccounts(), tt.input); len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+				t.Errorf("closestAccounts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckAccountID(t *testing.T) {
+	tests := []struct {
+		name      string
+		accountID string
+		token     string
+		wantCode  int
+	}{
+		{"empty", "", "token", 0},
+		{"valid", "111111111111", "token", 0},
+		{"name instead of id", "prod-app", "token", ExitInvalidSelection},
+		{"short without token", "11111111111", "", ExitInvalidSelection},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempCredentialsCache(t)
+			code := useExitCode(t)
+			calls := 0
+			checkAccountID(zerologTestingContext, catalogClient(testAccounts(), false, &calls), tt.token, tt.accountID)
+			if *code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", *code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestCheckRoleCredentialsError(t *testing.T) {
+	forbidden := &smithy.GenericAPIError{Code: "ForbiddenException", Message: "No access"}
+	tests := []struct {
+		name            string
+		accountID       string
+		roleName        string
+		err             error
+		wantCode        int
+		wantSuggestions []string
+	}{
+		{"no error", "111111111111", "Listed", nil, 0, nil},
+		{"other error", "111111111111", "Listed", errors.New("timeout"), 0, nil},
+		{"mistyped role", "111111111111", "Lsited", forbidden, ExitInvalidSelection, []string{"Listed in account 111111111111"}},
+		{"mistyped account", "111111111112", "Listed", forbidden, ExitInvalidSelection, []string{"111111111111 prod-app"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempCredentialsCache(t)
+			code := useExitCode(t)
+			calls := 0
+			c := catalogClient(testAccounts(), false, &calls)
+
+			if err := checkRoleCredentialsError(zerologTestingContext, c, "token", tt.accountID, tt.roleName, tt.err); !errors.Is(err, tt.err) {
+				t.Errorf("checkRoleCredentialsError() = %v, want %v", err, tt.err)
+			}
+			if *code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", *code, tt.wantCode)
+			}
+			if tt.wantCode == 0 {
+				return
+			}
+			if got := c.suggestSelection(zerologTestingContext, "token", tt.accountID, tt.roleName); !reflect.DeepEqual(got, tt.wantSuggestions) {
+				t.Errorf("suggestSelection() = %v, want %v", got, tt.wantSuggestions)
+			}
+		})
+	}
+}
+
+func TestSuggestSelectionListingFails(t *testing.T) {
+	useTempCredentialsCache(t)
+	c := NewSSOClient(&mockSSOClient{
+		ListAccountsAPI: func(ctx context.Context, params *sso.ListAccountsInput, optFns ...func(*sso.Options)) (*sso.ListAccountsOutput, error) {
+			return nil, errors.New("portal unreachable")
+		},
+	})
+	if got := c.suggestSelection(zerologTestingContext, "token", "111111111111", "Admin"); got != nil {
+		t.Errorf("suggestSelection() = %v, want none", got)
+	}
+}
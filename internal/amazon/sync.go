@@ -0,0 +1,238 @@
+package amazon
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/rs/zerolog"
+	ini "gopkg.in/ini.v1"
+
+	"ssoctx/internal/file"
+)
+
+// managedKey marks profiles written by Sync with the start url they were generated for
+const managedKey = "ssoctx_managed"
+
+// SyncFlagInputs contains all needed inputs for Sync
+type SyncFlagInputs struct {
+	StartURL        string
+	Region          string
+	ProfileTemplate string // names the profiles, defaults to DefaultProfileTemplate
+	Native          bool   // write native sso profiles to the aws config file instead of credential_process profiles
+	DryRun          bool   // only log the changes
+}
+
+// SSOProfileTemplate is what is expected in the aws config file for a native sso profile
+type SSOProfileTemplate struct {
+	SSOStartURL  string `ini:"sso_start_url"`
+	SSORegion    string `ini:"sso_region"`
+	SSOAccountID string `ini:"sso_account_id"`
+	SSORoleName  string `ini:"sso_role_name"`
+	Region       string `ini:"region,omitempty"`
+	Managed      string `ini:"ssoctx_managed,omitempty"`
+}
+
+// syncedProfile is a profile generated for an account and role
+type syncedProfile struct {
+	Name     string
+	Template interface{}
+}
+
+// syncChanges are the profile names changed by syncing
+type syncChanges struct {
+	Added, Updated, Removed, Skipped []string
+}
+
+// Sync is used to write a profile for every account and role, named by the profile template
+// Profiles are marked as managed, so profiles of access that was removed are pruned on the next sync.
+// Profiles not written by Sync are never replaced or removed.
+// Accounts and roles are always listed, so pruning is never driven by the cached catalog.
+func Sync(ctx context.Context, o *OIDCClientAPI, s *Client, inputs SyncFlagInputs) {
+	logger := zerolog.Ctx(ctx)
+	enforceStartURL(ctx, s, inputs.StartURL)
+
+	profileTemplate := inputs.ProfileTemplate
+	if len(profileTemplate) == 0 {
+		profileTemplate = DefaultProfileTemplate
+	}
+	tmpl, err := parseProfileTemplate(profileTemplate)
+	if err != nil {
+		logger.Fatal().Msgf("%v", err)
+	}
+
+	destination := clientInfoFileDestination(inputs.StartURL)
+	clientInformation, err := o.processClientInformation(ctx, destination)
+	if err != nil {
+		logger.Fatal().Msgf("Encountered error in processClientInformation: %v", err)
+	}
+	writeSecretToFile(ctx, &clientInformation, destination)
+	if len(inputs.StartURL) == 0 {
+		inputs.StartURL = clientInformation.StartURL
+	}
+
+	s.listLive()
+	pairs, err := s.accountRolePairs(ctx, clientInformation.AccessToken)
+	if err != nil {
+		logger.Fatal().Msgf("Encountered error listing accounts and roles: %v", err)
+	}
+
+	var profiles []syncedProfile
+	owners := map[string]string{}
+	for _, pair := range pairs {
+		accountID, roleName := aws.ToString(pair.Account.AccountId), aws.ToString(pair.Role.RoleName)
+		name, err := profileName(tmpl, pair.Account, roleName, s.labels.Metadata[accountID])
+		if err != nil {
+			logger.Fatal().Msgf("%v", err)
+		}
+		if owner, ok := owners[name]; ok {
+			logger.Fatal().Msgf("Profile %s is named the same for %s and %s %s, change --profile-template", name, owner, accountID, roleName)
+		}
+		owners[name] = fmt.Sprintf("%s %s", accountID, roleName)
+
+		if err := s.checkSyncPolicy(ctx, clientInformation.AccessToken, accountID, roleName, name); err != nil {
+			logger.Warn().Msgf("Skipping profile %s: %v", name, err)
+			continue
+		}
+		profiles = append(profiles, syncedProfile{Name: name, Template: syncTemplate(inputs, accountID, roleName)})
+	}
+
+	path, section := getCredentialsFilePath(), credentialsSection
+	if inputs.Native {
+		path, section = awsConfigFilePath(), configSection
+	}
+	profilesFile := ini.Empty()
+	if exists(ctx, path) {
+		if profilesFile, err = ini.Load(path); err != nil {
+			logger.Fatal().Msgf("Encountered error loading %s: %q", path, err)
+		}
+	}
+
+	changes, err := syncProfiles(profilesFile, profiles, section, inputs.StartURL)
+	if err != nil {
+		logger.Fatal().Msgf("%v", err)
+	}
+	for _, name := range changes.Skipped {
+		logger.Warn().Msgf("Skipping profile %s, it exists and is not managed by %s", name, ProjectFileName)
+	}
+	if inputs.DryRun {
+		logger.Info().Msgf("Would add %d profiles: %s", len(changes.Added), strings.Join(changes.Added, ", "))
+		logger.Info().Msgf("Would update %d profiles: %s", len(changes.Updated), strings.Join(changes.Updated, ", "))
+		logger.Info().Msgf("Would remove %d profiles: %s", len(changes.Removed), strings.Join(changes.Removed, ", "))
+		return
+	}
+	if !exists(ctx, path) {
+		createProfileFile(ctx, path)
+	}
+	if err := saveAtomically(profilesFile, path); err != nil {
+		logger.Fatal().Msgf("Encountered error saving %s: %q", path, err)
+	}
+	logger.Info().Msgf("Synced %d profiles to %s: %d added, %d updated, %d removed",
+		len(profiles)-len(changes.Skipped), path, len(changes.Added), len(changes.Updated), len(changes.Removed))
+}
+
+// checkSyncPolicy returns an error when the policy does not permit writing the profile of the account and role
+func (c *Client) checkSyncPolicy(ctx context.Context, accessToken, accountID, roleName, profile string) error {
+	if c.policy == nil {
+		return nil
+	}
+	if err := checkProfile(c.policy, profile); err != nil {
+		return err
+	}
+	names, named := c.lookupAccountNames(ctx, accessToken, accountID)
+	return checkOutput(ctx, c.policy, names, named, roleName, file.OutputProcess)
+}
+
+// syncTemplate returns the managed credential_process or native sso template of the account and role
+func syncTemplate(inputs SyncFlagInputs, accountID, roleName string) interface{} {
+	if inputs.Native {
+		return &SSOProfileTemplate{
+			SSOStartURL:  inputs.StartURL,
+			SSORegion:    inputs.Region,
+			SSOAccountID: accountID,
+			SSORoleName:  roleName,
+			Region:       inputs.Region,
+			Managed:      inputs.StartURL,
+		}
+	}
+	template := getCredentialProcess(accountID, roleName, inputs.Region, inputs.StartURL)
+	template.Managed = inputs.StartURL
+	return &template
+}
+
+// credentialsSection returns the section name of a profile in the credentials file
+func credentialsSection(profile string) string {
+	return profile
+}
+
+// configSection returns the section name of a profile in the aws config file
+func configSection(profile string) string {
+	if profile == defaultProfile {
+		return profile
+	}
+	return "profile " + profile
+}
+
+// syncProfiles writes the profiles and removes the profiles managed for the start url that are not synced anymore
+// Existing profiles that are not managed are skipped.
+func syncProfiles(profilesFile *ini.File, profiles []syncedProfile, section func(string) string, startURL string) (syncChanges, error) {
+	changes := syncChanges{}
+	synced := map[string]bool{}
+	for _, profile := range profiles {
+		name := section(profile.Name)
+		synced[name] = true
+
+		existing, err := profilesFile.GetSection(name)
+		if err == nil && !managedBy(existing, startURL) {
+			changes.Skipped = append(changes.Skipped, profile.Name)
+			continue
+		}
+
+		replacement := ini.Empty()
+		if err := replacement.Section(name).ReflectFrom(profile.Template); err != nil {
+			return changes, fmt.Errorf("encountered error reflecting template of profile %s: %w", profile.Name, err)
+		}
+		if existing != nil && sameKeys(existing, replacement.Section(name)) {
+			continue
+		}
+		if existing == nil {
+			changes.Added = append(changes.Added, profile.Name)
+		} else {
+			changes.Updated = append(changes.Updated, profile.Name)
+		}
+
+		// keys are replaced in place, so updated profiles keep their position in the file
+		target := profilesFile.Section(name)
+		for _, key := range target.KeyStrings() {
+			target.DeleteKey(key)
+		}
+		if err := target.ReflectFrom(profile.Template); err != nil {
+			return changes, fmt.Errorf("encountered error reflecting template of profile %s: %w", profile.Name, err)
+		}
+	}
+
+	for _, existing := range profilesFile.Sections() {
+		if managedBy(existing, startURL) && !synced[existing.Name()] {
+			changes.Removed = append(changes.Removed, strings.TrimPrefix(existing.Name(), "profile "))
+			profilesFile.DeleteSection(existing.Name())
+		}
+	}
+
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Updated)
+	sort.Strings(changes.Removed)
+	return changes, nil
+}
+
+// managedBy returns true when the profile was written by Sync for the start url
+// The key is only read when present, since Key would add it to profiles that are not managed.
+func managedBy(profile *ini.Section, startURL string) bool {
+	return profile.HasKey(managedKey) && profile.Key(managedKey).String() == startURL
+}
+
+// sameKeys returns true when both sections have the same keys and values
+func sameKeys(a, b *ini.Section) bool {
+	return fmt.Sprint(a.KeysHash()) == fmt.Sprint(b.KeysHash())
+}
@@ -0,0 +1,151 @@
+package amazon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	ini "gopkg.in/ini.v1"
+
+	"ssoctx/internal/file"
+)
+
+const otherStartURL = "https://other.awsapps.com/start"
+
+func TestSyncProfiles(t *testing.T) {
+	unchanged := getCredentialProcess("111111111111", "Admin", "us-east-1", testCatalogURL)
+	unchanged.Managed = testCatalogURL
+	existing := ini.Empty()
+	if err := existing.Section("prod-app-Admin").ReflectFrom(&unchanged); err != nil {
+		t.Fatal(err)
+	}
+	existing.Section("dev-app-Admin").Key("region").SetValue("us-east-1")
+	existing.Section("dev-app-Admin").Key(managedKey).SetValue(testCatalogURL)
+	existing.Section("removed-Admin").Key(managedKey).SetValue(testCatalogURL)
+	existing.Section("other-Admin").Key(managedKey).SetValue(otherStartURL)
+	existing.Section("prod-data-Admin").Key("region").SetValue("eu-west-1")
+
+	inputs := SyncFlagInputs{StartURL: testCatalogURL, Region: "us-east-1"}
+	var profiles []syncedProfile
+	for _, account := range testAccounts() {
+		name := aws.ToString(account.AccountName) + "-Admin"
+		profiles = append(profiles, syncedProfile{Name: name, Template: syncTemplate(inputs, aws.ToString(account.AccountId), "Admin")})
+	}
+	profiles = append(profiles, syncedProfile{Name: "new-Admin", Template: syncTemplate(inputs, "444444444444", "Admin")})
+
+	changes, err := syncProfiles(existing, profiles, credentialsSection, testCatalogURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := syncChanges{
+		Added:   []string{"new-Admin"},
+		Updated: []string{"dev-app-Admin"},
+		Removed: []string{"removed-Admin"},
+		Skipped: []string{"prod-data-Admin"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("syncProfiles() = %+v, want %+v", changes, want)
+	}
+	if got := existing.SectionStrings(); !reflect.DeepEqual(got, []string{ini.DefaultSection, "prod-app-Admin", "dev-app-Admin", "other-Admin", "prod-data-Admin", "new-Admin"}) {
+		t.Errorf("profiles after sync = %v", got)
+	}
+	if region := existing.Section("prod-data-Admin").Key("region").String(); region != "eu-west-1" {
+		t.Errorf("unmanaged profile region = %q, want kept eu-west-1", region)
+	}
+	for _, section := range []string{ini.DefaultSection, "prod-data-Admin"} {
+		if existing.Section(section).HasKey(managedKey) {
+			t.Errorf("unmanaged section %s gained the %s key", section, managedKey)
+		}
+	}
+	if process := existing.Section("dev-app-Admin").Key("credential_process").String(); !strings.Contains(process, "-a 222222222222 -n Admin") {
+		t.Errorf("updated profile credential_process = %q", process)
+	}
+}
+
+func TestConfigSection(t *testing.T) {
+	tests := []struct {
+		profile string
+		want    string
+	}{
+		{"default", "default"},
+		{"prod-app-Admin", "profile prod-app-Admin"},
+	}
+	for _, tt := range tests {
+		if got := configSection(tt.profile); got != tt.want {
+			t.Errorf("configSection(%q) = %q, want %q", tt.profile, got, tt.want)
+		}
+	}
+}
+
+func TestSync(t *testing.T) {
+	useTempCredentialsCache(t)
+	tokenPath := filepath.Join(t.TempDir(), "access-token.json")
+	mockClientInfoFileDestination = func(string) string { return tokenPath }
+	defer func() { mockClientInfoFileDestination = nil }()
+
+	c := NewSSOClient(&mockSSOClient{
+		ListAccountsAPI: func(ctx context.Context, params *sso.ListAccountsInput, optFns ...func(*sso.Options)) (*sso.ListAccountsOutput, error) {
+			return &sso.ListAccountsOutput{AccountList: testAccounts()}, nil
+		},
+		ListAccountRolesAPI: func(ctx context.Context, params *sso.ListAccountRolesInput, optFns ...func(*sso.Options)) (*sso.ListAccountRolesOutput, error) {
+			return &sso.ListAccountRolesOutput{RoleList: []types.RoleInfo{{RoleName: aws.String("ReadOnly")}}}, nil
+		},
+	})
+	c.UsePolicy(&file.Policy{Rules: []file.PolicyRule{{Account: "prod-data", Outputs: []string{file.OutputKeys}}}})
+	// a fresh catalog with revoked access must not be synced
+	c.UseCatalog(CatalogOptions{StartURL: testCatalogURL, TTL: time.Hour})
+	c.saveCatalogAccounts(zerologTestingContext, append(testAccounts(), types.AccountInfo{AccountId: aws.String("444444444444"), AccountName: aws.String("revoked")}))
+	c.updateCatalog(zerologTestingContext, func(cat *accountCatalog) {
+		cat.Roles["111111111111"] = cachedRoles{UpdatedAt: time.Now(), Roles: []types.RoleInfo{{RoleName: aws.String("Revoked")}}}
+	})
+	logins := 0
+	o := loginOIDCClient("token", &logins)
+
+	t.Run("dry run", func(t *testing.T) {
+		path := useTempCredentialsFile(t, "[keep]\nregion = us-east-1\n")
+		Sync(zerologTestingContext, o, c, SyncFlagInputs{StartURL: testCatalogURL, Region: "us-east-1", DryRun: true})
+		if content, _ := os.ReadFile(path); string(content) != "[keep]\nregion = us-east-1\n" {
+			t.Errorf("dry run changed the credentials file to %q", content)
+		}
+	})
+
+	t.Run("dry run without a credentials file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "credentials")
+		mockGetCredentialsFilePath = func() string { return path }
+		defer func() { mockGetCredentialsFilePath = nil }()
+
+		Sync(zerologTestingContext, o, c, SyncFlagInputs{StartURL: testCatalogURL, Region: "us-east-1", DryRun: true})
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("dry run created the credentials file: %v", err)
+		}
+	})
+
+	t.Run("native", func(t *testing.T) {
+		config := filepath.Join(t.TempDir(), "aws", "config")
+		original := awsConfigFilePath
+		awsConfigFilePath = func() string { return config }
+		defer func() { awsConfigFilePath = original }()
+
+		Sync(zerologTestingContext, o, c, SyncFlagInputs{StartURL: testCatalogURL, Region: "us-east-1", Native: true})
+
+		profiles, err := ini.Load(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{ini.DefaultSection, "profile prod-app-ReadOnly", "profile dev-app-ReadOnly"}
+		if got := profiles.SectionStrings(); !reflect.DeepEqual(got, want) {
+			t.Errorf("synced profiles = %v, want %v without the profile denied by policy", got, want)
+		}
+		section := profiles.Section("profile dev-app-ReadOnly")
+		if section.Key("sso_account_id").String() != "222222222222" || section.Key("sso_start_url").String() != testCatalogURL || section.Key(managedKey).String() != testCatalogURL {
+			t.Errorf("native profile = %v", section.KeysHash())
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v2"
+)
+
+// accountCatalogFile is the team shared file of account metadata
+type accountCatalogFile struct {
+	Accounts map[string]AccountMetadata `yaml:"accounts"`
+}
+
+// AccountMetadata returns the metadata per account id of the account catalog merged with the accounts of the config
+// Fields set in the config override the fields of the account catalog.
+func (c *AppConfig) AccountMetadata(ctx context.Context) map[string]AccountMetadata {
+	metadata, err := c.LoadAccountMetadata()
+	if err != nil {
+		zerolog.Ctx(ctx).Fatal().Msgf("%v", err)
+	}
+	return metadata
+}
+
+// LoadAccountMetadata returns the metadata of AccountMetadata or an error when the account catalog cannot be read
+func (c *AppConfig) LoadAccountMetadata() (map[string]AccountMetadata, error) {
+	if len(c.AccountCatalog) == 0 {
+		return c.Accounts, nil
+	}
+
+	bytes, err := os.ReadFile(expandHome(c.AccountCatalog))
+	if err != nil {
+		return nil, fmt.Errorf("encountered error reading account catalog: %q", err)
+	}
+	catalog := accountCatalogFile{}
+	if err := yaml.Unmarshal(bytes, &catalog); err != nil {
+		return nil, fmt.Errorf("encountered error in unmarshal of account catalog: %q", err)
+	}
+
+	metadata := make(map[string]AccountMetadata, len(catalog.Accounts)+len(c.Accounts))
+	for id, m := range catalog.Accounts {
+		metadata[id] = m
+	}
+	for id, m := range c.Accounts {
+		metadata[id] = mergeAccountMetadata(metadata[id], m)
+	}
+	return metadata, nil
+}
+
+// mergeAccountMetadata returns base with the fields set in override
+func mergeAccountMetadata(base, override AccountMetadata) AccountMetadata {
+	if len(override.Aliases) > 0 {
+		base.Aliases = override.Aliases
+	}
+	if len(override.Environment) > 0 {
+		base.Environment = override.Environment
+	}
+	if len(override.Team) > 0 {
+		base.Team = override.Team
+	}
+	if len(override.Color) > 0 {
+		base.Color = override.Color
+	}
+	if len(override.RolePreference) > 0 {
+		base.RolePreference = override.RolePreference
+	}
+	return base
+}
+
+// expandHome replaces a leading ~ with the home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homeDir, path[1:])
+}
@@ -0,0 +1,78 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestAccountMetadata(t *testing.T) {
+	ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+
+	catalog := filepath.Join(t.TempDir(), "accounts.yml")
+	content := `accounts:
+  "111111111111":
+    aliases: [prod]
+    environment: prod
+    team: payments
+    color: "9"
+  "222222222222":
+    environment: dev
+`
+	if err := os.WriteFile(catalog, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		conf AppConfig
+		want map[string]AccountMetadata
+	}{
+		{
+			name: "config only",
+			conf: AppConfig{Accounts: map[string]AccountMetadata{"111111111111": {Aliases: []string{"p"}}}},
+			want: map[string]AccountMetadata{"111111111111": {Aliases: []string{"p"}}},
+		},
+		{
+			name: "catalog only",
+			conf: AppConfig{AccountCatalog: catalog},
+			want: map[string]AccountMetadata{
+				"111111111111": {Aliases: []string{"prod"}, Environment: "prod", Team: "payments", Color: "9"},
+				"222222222222": {Environment: "dev"},
+			},
+		},
+		{
+			name: "config overrides catalog",
+			conf: AppConfig{
+				AccountCatalog: catalog,
+				Accounts: map[string]AccountMetadata{
+					"111111111111": {Team: "platform"},
+					"333333333333": {Environment: "stage"},
+				},
+			},
+			want: map[string]AccountMetadata{
+				"111111111111": {Aliases: []string{"prod"}, Environment: "prod", Team: "platform", Color: "9"},
+				"222222222222": {Environment: "dev"},
+				"333333333333": {Environment: "stage"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.conf.AccountMetadata(ctx); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AccountMetadata() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadAccountMetadataMissingCatalog(t *testing.T) {
+	conf := AppConfig{AccountCatalog: filepath.Join(t.TempDir(), "missing.yml")}
+	if _, err := conf.LoadAccountMetadata(); err == nil {
+		t.Errorf("LoadAccountMetadata() expected an error for a missing account catalog")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/rs/zerolog"
 	"gopkg.in/yaml.v2"
@@ -21,8 +22,83 @@ var (
 
 // AppConfig is used to save yaml config
 type AppConfig struct {
-	StartURL string `yaml:"start-url"`
-	Region   string `yaml:"region"`
+	StartURL        string                     `yaml:"start-url"`
+	Region          string                     `yaml:"region"`
+	Browser         string                     `yaml:"browser,omitempty"`
+	Picker          string                     `yaml:"picker,omitempty"`      // external picker command, like fzf
+	CatalogTTL      time.Duration              `yaml:"catalog-ttl,omitempty"` // how long cached accounts and roles are used
+	BrowserProfiles map[string]string          `yaml:"browser-profiles,omitempty"`
+	Chains          []RoleChain                `yaml:"chains,omitempty"`
+	ECR             ECRConfig                  `yaml:"ecr,omitempty"`
+	CodeCommit      []CodeCommitRepo           `yaml:"codecommit,omitempty"`
+	Favourites      []Favourite                `yaml:"favourites,omitempty"`
+	Accounts        map[string]AccountMetadata `yaml:"accounts,omitempty"`             // metadata per account id
+	AccountCatalog  string                     `yaml:"account-catalog,omitempty"`      // team shared yaml file of account metadata
+	GroupByEnv      bool                       `yaml:"group-by-environment,omitempty"` // group the account picker by environment
+	Safeguards      []Safeguard                `yaml:"safeguards,omitempty"`
+	RolePreference  []string                   `yaml:"role-preference,omitempty"` // roles chosen without prompting, first available wins
+}
+
+// Safeguard is used to require confirmation before handing out credentials of sensitive accounts and roles
+// Every pattern set has to match, patterns are globs ignoring case.
+type Safeguard struct {
+	Environment string        `yaml:"environment,omitempty"`  // account environment from the account metadata
+	Account     string        `yaml:"account,omitempty"`      // account id, name or alias
+	Role        string        `yaml:"role,omitempty"`         // permission set role name
+	MaxDuration time.Duration `yaml:"max-duration,omitempty"` // caps how long credentials are used before being fetched again
+}
+
+// AccountMetadata is used to describe an account beyond its SSO name
+type AccountMetadata struct {
+	Aliases        []string `yaml:"aliases,omitempty"`         // names accepted by --account
+	Environment    string   `yaml:"environment,omitempty"`     // like prod, stage or dev
+	Team           string   `yaml:"team,omitempty"`            // owning team
+	Color          string   `yaml:"color,omitempty"`           // picker color, an ansi number or hex like "#ff5f87"
+	RolePreference []string `yaml:"role-preference,omitempty"` // overrides the global role preference for the account
+}
+
+// Favourite is used to pin an account, and optionally its role, to the top of the pickers
+type Favourite struct {
+	AccountID string `yaml:"account-id"`
+	RoleName  string `yaml:"role-name,omitempty"`
+}
+
+// ECRConfig is used to map ecr registries to the role used per account
+type ECRConfig struct {
+	DefaultRole string            `yaml:"default-role,omitempty"`
+	Roles       map[string]string `yaml:"roles,omitempty"` // role name per account id
+}
+
+// CodeCommitRepo is used to map codecommit repositories to the account and role used for git credentials
+type CodeCommitRepo struct {
+	Region     string `yaml:"region,omitempty"`     // matches any region when empty
+	Repository string `yaml:"repository,omitempty"` // repository name or glob pattern, matches any when empty
+	AccountID  string `yaml:"account-id"`
+	RoleName   string `yaml:"role-name"`
+}
+
+// RoleChain is used to declare a role assumed on top of SSO role credentials
+type RoleChain struct {
+	Name           string            `yaml:"name"`
+	AccountID      string            `yaml:"account-id"`   // source SSO account id
+	RoleName       string            `yaml:"role-name"`    // source SSO permission set role name
+	RoleARN        string            `yaml:"role-arn"`     // target role to assume
+	SessionName    string            `yaml:"session-name"` // template rendered with AccountID, RoleName, Chain and User
+	Duration       time.Duration     `yaml:"duration,omitempty"`
+	ExternalID     string            `yaml:"external-id,omitempty"`
+	SourceIdentity string            `yaml:"source-identity,omitempty"`
+	MFASerial      string            `yaml:"mfa-serial,omitempty"`
+	Tags           map[string]string `yaml:"tags,omitempty"`
+}
+
+// Chain returns the role chain declared with the name
+func (c *AppConfig) Chain(name string) (*RoleChain, error) {
+	for i := range c.Chains {
+		if c.Chains[i].Name == name {
+			return &c.Chains[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no chain named %q found in config", name)
 }
 
 // GetConfigFilePath is the default config path
@@ -36,7 +112,8 @@ func GetConfigFilePath(ctx context.Context) string {
 }
 
 // GetConfigs reads the config and sets values.
-func GetConfigs(ctx context.Context, startURL, region *string) {
+// The config is returned for commands that need more than start url and region.
+func GetConfigs(ctx context.Context, startURL, region *string) *AppConfig {
 	conf := readConfigFunc(ctx, GetConfigFilePath(ctx))
 	if len(*startURL) == 0 {
 		*startURL = conf.StartURL
@@ -44,22 +121,31 @@ func GetConfigs(ctx context.Context, startURL, region *string) {
 	if len(*region) == 0 {
 		*region = conf.Region
 	}
+	return conf
 }
 
 // ReadConfig is used to read the config by filePath
 func ReadConfig(ctx context.Context, filePath string) *AppConfig {
 	logger := zerolog.Ctx(ctx)
+	appConfig, err := LoadConfig(filePath)
+	if err != nil {
+		logger.Fatal().Msgf("%v", err)
+	}
+	return appConfig
+}
+
+// LoadConfig is used to read the config by filePath, returning errors instead of exiting
+func LoadConfig(filePath string) (*AppConfig, error) {
 	bytes, err := os.ReadFile(filePath)
 	if err != nil {
-		logger.Fatal().Msgf("encountered error reading file path: %q", err)
+		return nil, fmt.Errorf("encountered error reading file path: %q", err)
 	}
 
 	appConfig := AppConfig{}
-	err = yaml.Unmarshal(bytes, &appConfig)
-	if err != nil {
-		logger.Fatal().Msgf("encountered error in unmarshal of config: %q", err)
+	if err := yaml.Unmarshal(bytes, &appConfig); err != nil {
+		return nil, fmt.Errorf("encountered error in unmarshal of config: %q", err)
 	}
-	return &appConfig
+	return &appConfig, nil
 }
 
 // GenerateConfig is used to generate a config yaml
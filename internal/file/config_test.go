@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/rs/zerolog"
 	"gopkg.in/yaml.v2"
@@ -190,3 +191,58 @@ func TestEditConfig(t *testing.T) {
 		t.Errorf("EditConfig() resulted in %v, want %v", editedConfig, expected)
 	}
 }
+
+func TestAppConfig_Chain(t *testing.T) {
+	content := []byte(`start-url: https://example.com
+region: us-west-2
+chains:
+  - name: workload-admin
+    account-id: "111111111111"
+    role-name: Developer
+    role-arn: arn:aws:iam::222222222222:role/Admin
+    session-name: "{{.User}}"
+    duration: 1h
+    external-id: abc
+`)
+	config := AppConfig{}
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		chain   string
+		want    *RoleChain
+		wantErr bool
+	}{
+		{
+			name:  "Chain found",
+			chain: "workload-admin",
+			want: &RoleChain{
+				Name:        "workload-admin",
+				AccountID:   "111111111111",
+				RoleName:    "Developer",
+				RoleARN:     "arn:aws:iam::222222222222:role/Admin",
+				SessionName: "{{.User}}",
+				Duration:    time.Hour,
+				ExternalID:  "abc",
+			},
+		},
+		{
+			name:    "Chain missing",
+			chain:   "missing",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := config.Chain(tt.chain)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Chain() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Chain() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v2"
+)
+
+// KubeconfigUser is used to write an exec credential plugin user to a kubeconfig
+type KubeconfigUser struct {
+	Name       string
+	APIVersion string
+	Command    string
+	Args       []string
+}
+
+// KubeconfigPath returns the kubeconfig path used by kubectl
+// The first path of KUBECONFIG is used, otherwise ${HOME}/.kube/config
+func KubeconfigPath() string {
+	if env := os.Getenv("KUBECONFIG"); len(env) > 0 {
+		return filepath.SplitList(env)[0]
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".kube", "config")
+}
+
+// WriteKubeconfigUser adds or replaces the user entry in the kubeconfig at path.
+// When contextName is set, that existing context is changed to use the user.
+// Everything else in the kubeconfig is kept as is.
+func WriteKubeconfigUser(ctx context.Context, path string, user KubeconfigUser, contextName string) error {
+	logger := zerolog.Ctx(ctx)
+	if len(path) == 0 {
+		path = KubeconfigPath()
+	}
+
+	kubeconfig := yaml.MapSlice{}
+	bytes, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("encountered error reading kubeconfig: %w", err)
+	}
+	if err := yaml.Unmarshal(bytes, &kubeconfig); err != nil {
+		return fmt.Errorf("encountered error in unmarshal of kubeconfig: %w", err)
+	}
+	if len(kubeconfig) == 0 {
+		kubeconfig = yaml.MapSlice{
+			{Key: "apiVersion", Value: "v1"},
+			{Key: "kind", Value: "Config"},
+		}
+	}
+
+	entry := yaml.MapSlice{
+		{Key: "name", Value: user.Name},
+		{Key: "user", Value: yaml.MapSlice{
+			{Key: "exec", Value: yaml.MapSlice{
+				{Key: "apiVersion", Value: user.APIVersion},
+				{Key: "command", Value: user.Command},
+				{Key: "args", Value: user.Args},
+				{Key: "interactiveMode", Value: "IfAvailable"},
+				{Key: "provideClusterInfo", Value: false},
+			}},
+		}},
+	}
+	kubeconfig = setMapValue(kubeconfig, "users", replaceNamedEntry(mapValue(kubeconfig, "users"), user.Name, entry))
+
+	if len(contextName) > 0 {
+		contexts, found := setContextUser(mapValue(kubeconfig, "contexts"), contextName, user.Name)
+		if !found {
+			return fmt.Errorf("context %q not found in kubeconfig %s", contextName, path)
+		}
+		kubeconfig = setMapValue(kubeconfig, "contexts", contexts)
+	}
+
+	out, err := yaml.Marshal(kubeconfig)
+	if err != nil {
+		return fmt.Errorf("encountered error in marshal of kubeconfig: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("encountered error making kubeconfig dir: %w", err)
+	}
+	if err := os.WriteFile(path, out, 0o600); err != nil {
+		return fmt.Errorf("encountered error writing kubeconfig: %w", err)
+	}
+	logger.Debug().Msgf("kubeconfig written: %s", path)
+	return nil
+}
+
+// mapValue returns the value of key in a MapSlice
+func mapValue(ms yaml.MapSlice, key string) interface{} {
+	for _, item := range ms {
+		if item.Key == key {
+			return item.Value
+		}
+	}
+	return nil
+}
+
+// setMapValue sets the value of key in a MapSlice, keeping the key order
+func setMapValue(ms yaml.MapSlice, key string, value interface{}) yaml.MapSlice {
+	for i, item := range ms {
+		if item.Key == key {
+			ms[i].Value = value
+			return ms
+		}
+	}
+	return append(ms, yaml.MapItem{Key: key, Value: value})
+}
+
+// replaceNamedEntry replaces the list entry with the name or appends it
+func replaceNamedEntry(list interface{}, name string, entry yaml.MapSlice) []interface{} {
+	entries, _ := list.([]interface{})
+	for i, item := range entries {
+		if ms, ok := item.(yaml.MapSlice); ok && mapValue(ms, "name") == name {
+			entries[i] = entry
+			return entries
+		}
+	}
+	return append(entries, entry)
+}
+
+// setContextUser sets the user of the named context
+func setContextUser(list interface{}, contextName, userName string) ([]interface{}, bool) {
+	entries, _ := list.([]interface{})
+	for i, item := range entries {
+		ms, ok := item.(yaml.MapSlice)
+		if !ok || mapValue(ms, "name") != contextName {
+			continue
+		}
+		contextEntry, _ := mapValue(ms, "context").(yaml.MapSlice)
+		entries[i] = setMapValue(ms, "context", setMapValue(contextEntry, "user", userName))
+		return entries, true
+	}
+	return entries, false
+}
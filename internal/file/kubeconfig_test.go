@@ -0,0 +1,114 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v2"
+)
+
+const existingKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: prod
+  cluster:
+    server: https://prod.example.com
+contexts:
+- name: prod
+  context:
+    cluster: prod
+    user: old-user
+users:
+- name: old-user
+  user:
+    token: abc
+- name: ssoctx-prod
+  user:
+    token: stale
+current-context: prod
+`
+
+func TestWriteKubeconfigUser(t *testing.T) {
+	ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+	user := KubeconfigUser{
+		Name:       "ssoctx-prod",
+		APIVersion: "client.authentication.k8s.io/v1beta1",
+		Command:    "ssoctx",
+		Args:       []string{"eks", "token", "--cluster", "prod"},
+	}
+
+	tests := []struct {
+		name        string
+		existing    string
+		contextName string
+		wantUsers   int
+		wantContext string
+		wantErr     bool
+	}{
+		{
+			name:      "New kubeconfig",
+			wantUsers: 1,
+		},
+		{
+			name:        "Replace user and set context",
+			existing:    existingKubeconfig,
+			contextName: "prod",
+			wantUsers:   2,
+			wantContext: "ssoctx-prod",
+		},
+		{
+			name:        "Missing context",
+			existing:    existingKubeconfig,
+			contextName: "missing",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config")
+			if len(tt.existing) > 0 {
+				if err := os.WriteFile(path, []byte(tt.existing), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := WriteKubeconfigUser(ctx, path, user, tt.contextName)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WriteKubeconfigUser() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			bytes, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			kubeconfig := yaml.MapSlice{}
+			if err := yaml.Unmarshal(bytes, &kubeconfig); err != nil {
+				t.Fatal(err)
+			}
+			users, _ := mapValue(kubeconfig, "users").([]interface{})
+			if len(users) != tt.wantUsers {
+				t.Errorf("WriteKubeconfigUser() wrote %d users, want %d", len(users), tt.wantUsers)
+			}
+			if !strings.Contains(string(bytes), "command: ssoctx") || strings.Contains(string(bytes), "stale") {
+				t.Errorf("WriteKubeconfigUser() did not replace user entry:\n%s", bytes)
+			}
+			if len(tt.wantContext) > 0 {
+				contexts, _ := mapValue(kubeconfig, "contexts").([]interface{})
+				contextEntry, _ := mapValue(contexts[0].(yaml.MapSlice), "context").(yaml.MapSlice)
+				if mapValue(contextEntry, "user") != tt.wantContext {
+					t.Errorf("WriteKubeconfigUser() context user = %v, want %v", mapValue(contextEntry, "user"), tt.wantContext)
+				}
+				if mapValue(kubeconfig, "current-context") != "prod" {
+					t.Errorf("WriteKubeconfigUser() did not keep current-context")
+				}
+			}
+		})
+	}
+}
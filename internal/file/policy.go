@@ -0,0 +1,65 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v2"
+)
+
+// PolicyEnv is the environment variable set to the path of the policy file
+const PolicyEnv = "SSOCTX_POLICY"
+
+// Output modes credentials are handed out in, used by PolicyRule
+const (
+	OutputKeys    = "keys"    // access keys persisted to the credentials file
+	OutputProcess = "process" // credential_process profiles, assume and the credential helpers
+	OutputExec    = "exec"    // environment of a child process
+	OutputPrint   = "print"   // environment variables printed by print-creds
+	OutputConsole = "console" // federated console sign-in url
+)
+
+// Policy is used to restrict how credentials are handed out, distributed by an organization next to the config
+type Policy struct {
+	StartURLs        []string     `yaml:"start-urls,omitempty"`        // permitted start urls, any when empty
+	ReservedProfiles []string     `yaml:"reserved-profiles,omitempty"` // profile globs never written
+	Rules            []PolicyRule `yaml:"rules,omitempty"`             // the first rule matching an account and role applies
+}
+
+// PolicyRule is used to allow output modes for the accounts and roles matching its globs
+// A matching rule without outputs denies the account and role.
+type PolicyRule struct {
+	Account string   `yaml:"account,omitempty"` // account id, name or alias, any when empty
+	Role    string   `yaml:"role,omitempty"`    // permission set role name, any when empty
+	Outputs []string `yaml:"outputs,omitempty"` // allowed output modes: keys, process, exec, print and console
+}
+
+// GetPolicyFilePath returns the policy file set with PolicyEnv, otherwise policy.yml next to the config
+func GetPolicyFilePath(ctx context.Context) string {
+	if path, ok := os.LookupEnv(PolicyEnv); ok && len(path) > 0 {
+		return expandHome(path)
+	}
+	return filepath.Join(filepath.Dir(getConfigFilePathFunc(ctx)), "policy.yml")
+}
+
+// ReadPolicy reads the policy file, an empty policy is returned when there is none
+// A policy set with PolicyEnv has to exist, so a missing org policy is not silently skipped.
+func ReadPolicy(ctx context.Context) *Policy {
+	logger := zerolog.Ctx(ctx)
+	filePath := GetPolicyFilePath(ctx)
+	bytes, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) && len(os.Getenv(PolicyEnv)) == 0 {
+		return &Policy{}
+	}
+	if err != nil {
+		logger.Fatal().Msgf("encountered error reading policy file: %q", err)
+	}
+
+	policy := Policy{}
+	if err := yaml.UnmarshalStrict(bytes, &policy); err != nil {
+		logger.Fatal().Msgf("encountered error in unmarshal of policy: %q", err)
+	}
+	return &policy
+}
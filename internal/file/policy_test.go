@@ -0,0 +1,53 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestReadPolicy(t *testing.T) {
+	ctx := zerolog.New(os.Stdout).WithContext(context.Background())
+	configDir := t.TempDir()
+	originalPath := getConfigFilePathFunc
+	defer func() { getConfigFilePathFunc = originalPath }()
+	getConfigFilePathFunc = func(ctx context.Context) string {
+		return filepath.Join(configDir, "config.yml")
+	}
+
+	t.Setenv(PolicyEnv, "")
+	if got := ReadPolicy(ctx); !reflect.DeepEqual(got, &Policy{}) {
+		t.Errorf("ReadPolicy() without policy = %v, want empty", got)
+	}
+
+	content := `start-urls: [https://example.awsapps.com/start]
+reserved-profiles: [default]
+rules:
+  - role: "*Admin*"
+    outputs: [process, exec]
+`
+	want := &Policy{
+		StartURLs:        []string{"https://example.awsapps.com/start"},
+		ReservedProfiles: []string{"default"},
+		Rules:            []PolicyRule{{Role: "*Admin*", Outputs: []string{OutputProcess, OutputExec}}},
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "policy.yml"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := ReadPolicy(ctx); !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadPolicy() next to config = %v, want %v", got, want)
+	}
+
+	orgPolicy := filepath.Join(t.TempDir(), "org.yml")
+	if err := os.WriteFile(orgPolicy, []byte("reserved-profiles: [org-*]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(PolicyEnv, orgPolicy)
+	if got := ReadPolicy(ctx); !reflect.DeepEqual(got, &Policy{ReservedProfiles: []string{"org-*"}}) {
+		t.Errorf("ReadPolicy() from %s = %v", PolicyEnv, got)
+	}
+}
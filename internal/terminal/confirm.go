@@ -0,0 +1,51 @@
+package terminal
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var bannerStyle = lipgloss.NewStyle().
+	Bold(true).
+	Foreground(lipgloss.Color("15")).
+	Background(lipgloss.Color("9")).
+	Padding(0, 2)
+
+// Banner writes the lines as a loud colored block, used before handing out sensitive credentials
+func Banner(w io.Writer, lines ...string) {
+	fmt.Fprintln(w, bannerStyle.Render(strings.Join(lines, "\n")))
+}
+
+// NewTypedConfirmForm asks to type one of the expected values before continuing
+// The form cannot be submitted until the input matches, aborting returns huh.ErrUserAborted.
+func NewTypedConfirmForm(title string, expected []string) error {
+	var typed string
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title(title).
+				Value(&typed).
+				Validate(func(input string) error {
+					if !TypedConfirmation(input, expected) {
+						return fmt.Errorf("type %s to continue", expected[0])
+					}
+					return nil
+				}),
+		),
+	).Run()
+}
+
+// TypedConfirmation returns true when the input is one of the expected values, ignoring surrounding spaces
+func TypedConfirmation(input string, expected []string) bool {
+	input = strings.TrimSpace(input)
+	for _, value := range expected {
+		if len(value) > 0 && input == value {
+			return true
+		}
+	}
+	return false
+}
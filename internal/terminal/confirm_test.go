@@ -0,0 +1,39 @@
+package terminal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTypedConfirmation(t *testing.T) {
+	expected := []string{"prod-app", "111111111111"}
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{"name", "prod-app", true},
+		{"id with spaces", " 111111111111 ", true},
+		{"case differs", "Prod-App", false},
+		{"empty", "", false},
+		{"other", "yes", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TypedConfirmation(tt.input, expected); got != tt.want {
+				t.Errorf("TypedConfirmation(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBanner(t *testing.T) {
+	var b bytes.Buffer
+	Banner(&b, "SAFEGUARDED ACCOUNT", "account 111111111111 [prod]")
+	for _, line := range []string{"SAFEGUARDED ACCOUNT", "account 111111111111 [prod]"} {
+		if !strings.Contains(b.String(), line) {
+			t.Errorf("Banner() = %q, want %q", b.String(), line)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package terminal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/huh"
+)
+
+// pickerTitleEnv is set to the title of the selection for the external picker command
+const pickerTitleEnv = "SSOCTX_PICKER_TITLE"
+
+// Selector returns the external picker for the command, or NewSelectForm when the command is empty
+func Selector[T comparable](command string) SelectorFunc[T] {
+	if len(command) == 0 {
+		return NewSelectForm[T]
+	}
+	return NewExternalSelector[T](command)
+}
+
+// NewExternalSelector creates a selector backed by an external picker command like fzf, skim or rofi -dmenu
+// Option keys are written as lines to the command and the line it outputs is the selection.
+// The command is run by the shell with the title in SSOCTX_PICKER_TITLE.
+func NewExternalSelector[T comparable](command string) SelectorFunc[T] {
+	return func(options []huh.Option[T], title string) (T, error) {
+		pages := make(chan OptionPage[T], 1)
+		pages <- OptionPage[T]{Options: options}
+		close(pages)
+		return NewExternalStreamSelector[T](command)(pages, title)
+	}
+}
+
+// NewExternalStreamSelector creates a stream selector backed by an external picker command
+// Options are written to the command as pages arrive, so pickers reading their input lazily can be used while listing.
+func NewExternalStreamSelector[T comparable](command string) StreamSelectorFunc[T] {
+	return func(pages <-chan OptionPage[T], title string) (T, error) {
+		var (
+			output T
+			mu     sync.Mutex
+			values = map[string]T{}
+		)
+
+		chosen, err := runPicker(command, title, func(w io.Writer) {
+			for page := range pages {
+				for _, option := range page.Options {
+					mu.Lock()
+					_, seen := values[option.Key]
+					values[option.Key] = option.Value
+					mu.Unlock()
+					if !seen {
+						// the picker may exit before every option is written
+						_, _ = fmt.Fprintln(w, option.Key)
+					}
+				}
+			}
+		})
+		if err != nil {
+			return output, err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		value, ok := values[chosen]
+		if !ok {
+			return output, fmt.Errorf("picker output %q is not one of the options", chosen)
+		}
+		return value, nil
+	}
+}
+
+// runPicker runs the picker command with the lines written by input and returns the line it outputs
+// An empty output, like fzf exiting on escape, returns huh.ErrUserAborted
+func runPicker(command, title string, input func(io.Writer)) (string, error) {
+	cmd := pickerCommand(command)
+	cmd.Env = append(os.Environ(), pickerTitleEnv+"="+title)
+	cmd.Stderr = os.Stderr
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("encountered error starting picker %q: %w", command, err)
+	}
+	go func() {
+		defer stdin.Close()
+		input(stdin)
+	}()
+
+	err = cmd.Wait()
+	chosen := strings.TrimRight(stdout.String(), "\r\n")
+	if i := strings.IndexAny(chosen, "\r\n"); i >= 0 {
+		// multi select pickers output a line per selection, the first one is used
+		chosen = chosen[:i]
+	}
+	if len(chosen) == 0 {
+		return "", huh.ErrUserAborted
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return "", fmt.Errorf("encountered error running picker %q: %w", command, err)
+	}
+	return chosen, nil
+}
+
+// pickerCommand returns the command run by the shell of the platform
+func pickerCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
@@ -0,0 +1,62 @@
+package terminal
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/charmbracelet/huh"
+)
+
+func TestExternalSelector(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("picker commands are run by sh")
+	}
+	tests := []struct {
+		name    string
+		command string
+		want    string
+		wantErr error
+	}{
+		{"first line", "head -n 1", "alpha", nil},
+		{"filtered line", "grep charlie", "charlie", nil},
+		{"multiple lines", "grep a", "alpha", nil},
+		{"title", `grep "$SSOCTX_PICKER_TITLE"`, "bravo", nil},
+		{"aborted", "cat > /dev/null; exit 130", "", huh.ErrUserAborted},
+		{"no match", "grep zulu", "", huh.ErrUserAborted},
+		{"unknown output", "echo zulu", "", errors.New("picker output")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewExternalSelector[string](tt.command)(GenerateGenericOptions([]string{"alpha", "bravo", "charlie"}), "bravo")
+			if (err != nil) != (tt.wantErr != nil) || (errors.Is(tt.wantErr, huh.ErrUserAborted) && !errors.Is(err, huh.ErrUserAborted)) {
+				t.Fatalf("NewExternalSelector() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NewExternalSelector() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExternalStreamSelector(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("picker commands are run by sh")
+	}
+	pages := make(chan OptionPage[string])
+	go func() {
+		defer close(pages)
+		pages <- page("alpha", "bravo")
+		pages <- OptionPage[string]{Status: "throttled"}
+		pages <- page("bravo", "charlie")
+	}()
+
+	// picks charlie only when every option is written once
+	got, err := NewExternalStreamSelector[string](`awk 'END { print NR == 3 ? "charlie" : "alpha" }'`)(pages, "Select")
+	if err != nil {
+		t.Fatalf("NewExternalStreamSelector() error = %v", err)
+	}
+	if got != "charlie" {
+		t.Errorf("NewExternalStreamSelector() = %q, want charlie", got)
+	}
+}
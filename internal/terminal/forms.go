@@ -24,14 +24,17 @@ func SelectRegion(selector SelectorFunc[string]) string {
 }
 
 // SelectRole is used to return a pointer to the selected Role
+// Preferred role names are listed first in order and the first one found is preselected.
 // Pass in a NewSelectForm[types.RoleInfo]
-func SelectRole(roles *sso.ListAccountRolesOutput, selector SelectorFunc[types.RoleInfo]) (*types.RoleInfo, error) {
+func SelectRole(roles *sso.ListAccountRolesOutput, preferred []string, selector SelectorFunc[types.RoleInfo]) (*types.RoleInfo, error) {
 	if len(roles.RoleList) == 1 {
 		return &roles.RoleList[0], nil
 	}
 
 	label := "Select your role"
-	options := generateRoleInfoOptions(roles.RoleList)
+	options := preferOptions(generateRoleInfoOptions(roles.RoleList), preferred, func(role types.RoleInfo) string {
+		return *role.RoleName
+	})
 	selectedRole, err := selector(options, label)
 	if err != nil {
 		return &types.RoleInfo{}, err
@@ -39,26 +42,29 @@ func SelectRole(roles *sso.ListAccountRolesOutput, selector SelectorFunc[types.R
 	return &selectedRole, nil
 }
 
-// SelectAccount is used to return a pointer to the selected Account
-// Pass in a NewSelectForm[types.AccountInfo]
-func SelectAccount(accounts *sso.ListAccountsOutput, selector SelectorFunc[types.AccountInfo]) (*types.AccountInfo, error) {
-	label := "Select your account"
-	sortedAccounts := sortAccounts(accounts.AccountList)
-	options := generateAccountInfoOptions(sortedAccounts)
-	selectedAccount, err := selector(options, label)
-	if err != nil {
-		return &types.AccountInfo{}, err
+// preferOptions moves the options whose id is preferred to the top in the preferred order
+// The first preferred option is selected, the other options keep their order
+func preferOptions[T comparable](options []huh.Option[T], preferred []string, id func(T) string) []huh.Option[T] {
+	rank := make(map[string]int, len(preferred))
+	for i, p := range preferred {
+		if _, ok := rank[p]; !ok {
+			rank[p] = i
+		}
 	}
 
-	return &selectedAccount, nil
-}
-
-func sortAccounts(accountList []types.AccountInfo) []types.AccountInfo {
-	var sortedAccounts []types.AccountInfo
-
-	sortedAccounts = append(sortedAccounts, accountList...)
-	sort.Slice(sortedAccounts, func(i, j int) bool {
-		return *sortedAccounts[i].AccountName < *sortedAccounts[j].AccountName
+	var first, rest []huh.Option[T]
+	for _, option := range options {
+		if _, ok := rank[id(option.Value)]; ok {
+			first = append(first, option)
+		} else {
+			rest = append(rest, option)
+		}
+	}
+	sort.SliceStable(first, func(i, j int) bool {
+		return rank[id(first[i].Value)] < rank[id(first[j].Value)]
 	})
-	return sortedAccounts
+	if len(first) > 0 {
+		first[0] = first[0].Selected(true)
+	}
+	return append(first, rest...)
 }
@@ -2,6 +2,7 @@ package terminal
 
 import (
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/service/sso"
@@ -97,7 +98,7 @@ func TestSelectRole(t *testing.T) {
 				return tt.mockReturn, tt.mockError
 			}
 
-			_, err := SelectRole(tt.roles, mockSelector)
+			_, err := SelectRole(tt.roles, nil, mockSelector)
 			if (err != nil) != tt.expectedError {
 				t.Errorf("SelectRole() error = %v, expectedError %v", err, tt.expectedError)
 				return
@@ -106,96 +107,27 @@ func TestSelectRole(t *testing.T) {
 	}
 }
 
-func TestSelectAccount(t *testing.T) {
+func TestPreferOptions(t *testing.T) {
 	tests := []struct {
-		name           string
-		accounts       *sso.ListAccountsOutput
-		mockReturn     types.AccountInfo
-		mockError      error
-		expectedResult *types.AccountInfo
-		expectedError  bool
+		name      string
+		preferred []string
+		want      []string
 	}{
-		{
-			name: "Successful selection",
-			accounts: &sso.ListAccountsOutput{
-				AccountList: []types.AccountInfo{
-					{AccountName: ptr("Account1"), AccountId: ptr("123")},
-					{AccountName: ptr("Account2"), AccountId: ptr("456")},
-				},
-			},
-			mockReturn:     types.AccountInfo{AccountName: ptr("Account2"), AccountId: ptr("456")},
-			mockError:      nil,
-			expectedResult: &types.AccountInfo{AccountName: ptr("Account2"), AccountId: ptr("456")},
-			expectedError:  false,
-		},
-		{
-			name: "Error in selection",
-			accounts: &sso.ListAccountsOutput{
-				AccountList: []types.AccountInfo{
-					{AccountName: ptr("Account1"), AccountId: ptr("123")},
-					{AccountName: ptr("Account2"), AccountId: ptr("456")},
-				},
-			},
-			mockReturn:     types.AccountInfo{},
-			mockError:      errors.New("mock error"),
-			expectedResult: &types.AccountInfo{},
-			expectedError:  true,
-		},
+		{"none", nil, []string{"alpha", "bravo", "charlie"}},
+		{"preferred order", []string{"charlie", "missing", "bravo"}, []string{"charlie", "bravo", "alpha"}},
+		{"repeated", []string{"bravo", "bravo"}, []string{"bravo", "alpha", "charlie"}},
 	}
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockSelector := func(options []huh.Option[types.AccountInfo], title string) (types.AccountInfo, error) {
-				return tt.mockReturn, tt.mockError
-			}
+			options := preferOptions(GenerateGenericOptions([]string{"alpha", "bravo", "charlie"}), tt.preferred, func(s string) string { return s })
 
-			_, err := SelectAccount(tt.accounts, mockSelector)
-			if (err != nil) != tt.expectedError {
-				t.Errorf("SelectAccount() error = %v, expectedError %v", err, tt.expectedError)
-				return
+			var keys []string
+			for _, option := range options {
+				keys = append(keys, option.Key)
+			}
+			if strings.Join(keys, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("preferOptions() = %v, want %v", keys, tt.want)
 			}
-		})
-	}
-}
-
-func TestSortAccounts(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    []types.AccountInfo
-		expected []types.AccountInfo
-	}{
-		{
-			name: "Sort accounts",
-			input: []types.AccountInfo{
-				{AccountName: ptr("C Account"), AccountId: ptr("3")},
-				{AccountName: ptr("A Account"), AccountId: ptr("1")},
-				{AccountName: ptr("B Account"), AccountId: ptr("2")},
-			},
-			expected: []types.AccountInfo{
-				{AccountName: ptr("A Account"), AccountId: ptr("1")},
-				{AccountName: ptr("B Account"), AccountId: ptr("2")},
-				{AccountName: ptr("C Account"), AccountId: ptr("3")},
-			},
-		},
-		{
-			name:     "Empty list",
-			input:    []types.AccountInfo{},
-			expected: []types.AccountInfo{},
-		},
-		{
-			name: "Single account",
-			input: []types.AccountInfo{
-				{AccountName: ptr("A Account"), AccountId: ptr("1")},
-			},
-			expected: []types.AccountInfo{
-				{AccountName: ptr("A Account"), AccountId: ptr("1")},
-			},
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			sortAccounts(tt.input)
 		})
 	}
 }
@@ -0,0 +1,149 @@
+package terminal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/charmbracelet/huh"
+)
+
+// AccountRole is an account and one of its roles
+// Role is empty when the roles of the account are not cached yet
+type AccountRole struct {
+	Account types.AccountInfo
+	Role    types.RoleInfo
+}
+
+// ID returns the account id and role name used to prefer pairs
+func (p AccountRole) ID() string {
+	return aws.ToString(p.Account.AccountId) + "/" + aws.ToString(p.Role.RoleName)
+}
+
+// SelectAccountRole is used to return a pointer to the selected account and role pair
+// The pairs are sent as a single page, colored and described by the account labels.
+// Preferred pair ids are listed first in order and the first one found is preselected.
+// Every word typed has to match, so "prod admin" matches "prod-app 1111 / Admin".
+// Pass in a NewStreamSelectForm[AccountRole]
+func SelectAccountRole(pairs []AccountRole, labels AccountLabels, preferred []string, selector StreamSelectorFunc[AccountRole]) (*AccountRole, error) {
+	label := "Select your account and role"
+	options := preferOptions(generateAccountRoleOptions(pairs, labels), preferred, AccountRole.ID)
+	pages := make(chan OptionPage[AccountRole], 1)
+	pages <- OptionPage[AccountRole]{
+		Options: options,
+		Pinned:  true,
+		Colors:  accountColors(options, labels, func(pair AccountRole) types.AccountInfo { return pair.Account }),
+	}
+	close(pages)
+	selected, err := selector(pages, label)
+	if err != nil {
+		return &AccountRole{}, err
+	}
+	return &selected, nil
+}
+
+// MultiSelectorFunc is a generic function type for selecting several options
+type MultiSelectorFunc[T comparable] func([]huh.Option[T], string) ([]T, error)
+
+// NewMultiSelectForm creates a filterable form to select several options
+func NewMultiSelectForm[T comparable](options []huh.Option[T], title string) ([]T, error) {
+	var output []T
+
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[T]().
+				Title(title).
+				Options(options...).
+				Filterable(true).
+				Height(streamSelectHeight + 2).
+				Value(&output),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// SelectAccountRoles is used to return the selected account and role pairs
+// Preferred pair ids are listed first in order.
+// Pass in a NewMultiSelectForm[AccountRole]
+func SelectAccountRoles(pairs []AccountRole, labels AccountLabels, preferred []string, selector MultiSelectorFunc[AccountRole]) ([]AccountRole, error) {
+	label := "Select accounts and roles, space to toggle"
+	options := preferOptions(generateAccountRoleOptions(pairs, labels), preferred, AccountRole.ID)
+	for i := range options {
+		// preferOptions preselects the first preferred pair, which a multi select would toggle on
+		options[i] = options[i].Selected(false)
+	}
+	selected, err := selector(options, label)
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) == 0 {
+		return nil, huh.ErrUserAborted
+	}
+	return selected, nil
+}
+
+// generateAccountRoleOptions generates AccountRole options sorted by key
+// Keys start with the account key, so the environment, aliases and team are shown and grouped like the account picker.
+func generateAccountRoleOptions(pairs []AccountRole, labels AccountLabels) []huh.Option[AccountRole] {
+	options := make([]huh.Option[AccountRole], len(pairs))
+	for i, pair := range pairs {
+		role := aws.ToString(pair.Role.RoleName)
+		if len(role) == 0 {
+			role = "(choose role)"
+		}
+		options[i] = huh.Option[AccountRole]{
+			Key:   fmt.Sprintf("%s / %s / %s", accountKey(pair.Account, labels), aws.ToString(pair.Account.EmailAddress), role),
+			Value: pair,
+		}
+	}
+	sort.SliceStable(options, func(i, j int) bool {
+		return options[i].Key < options[j].Key
+	})
+	return options
+}
+
+// fuzzyScore returns how well the key matches the filter, ignoring case
+// Every word of the filter has to be found in the key, in order of its characters.
+// Words found as a substring score higher, more so at the start of a word of the key.
+func fuzzyScore(key, filter string) (int, bool) {
+	key = strings.ToLower(key)
+	score := 0
+	for _, term := range strings.Fields(strings.ToLower(filter)) {
+		if i := strings.Index(key, term); i >= 0 {
+			score += 2 * len(term)
+			if i == 0 || !isWordRune(rune(key[i-1])) {
+				score += len(term)
+			}
+			continue
+		}
+		if !isSubsequence(key, term) {
+			return 0, false
+		}
+		score += len(term)
+	}
+	return score, true
+}
+
+// isSubsequence returns true when the characters of term are found in key in order
+func isSubsequence(key, term string) bool {
+	remaining := []rune(term)
+	for _, r := range key {
+		if len(remaining) == 0 {
+			break
+		}
+		if r == remaining[0] {
+			remaining = remaining[1:]
+		}
+	}
+	return len(remaining) == 0
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
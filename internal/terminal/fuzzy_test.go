@@ -0,0 +1,97 @@
+package terminal
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ssoctx/internal/file"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       string
+		filter    string
+		wantMatch bool
+	}{
+		{"empty filter", "prod-app / 111111111111 / Admin", "", true},
+		{"words in any order", "prod-app / 111111111111 / Admin", "admin prod", true},
+		{"subsequence", "prod-app / 111111111111 / Admin", "pdap", true},
+		{"case insensitive", "prod-app / 111111111111 / Admin", "PROD ADMIN", true},
+		{"one word missing", "prod-app / 111111111111 / Admin", "prod readonly", false},
+		{"out of order characters", "prod-app / 111111111111 / Admin", "nimda", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := fuzzyScore(tt.key, tt.filter); ok != tt.wantMatch {
+				t.Errorf("fuzzyScore(%q, %q) match = %v, want %v", tt.key, tt.filter, ok, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestFuzzyScoreOrder(t *testing.T) {
+	// substrings at the start of a word beat substrings within a word, which beat subsequences
+	keys := []string{"dev / ReadOnlyAdmin", "prod / Admin", "dev / SysAdmin", "dev / Audit Manager"}
+	scores := make([]int, len(keys))
+	for i, key := range keys {
+		scores[i], _ = fuzzyScore(key, "admin")
+	}
+	if !(scores[1] > scores[2] && scores[2] > scores[3]) || scores[0] != scores[2] {
+		t.Errorf("fuzzyScore() = %v, want word start > within word > subsequence", scores)
+	}
+}
+
+func TestStreamSelectModelFuzzy(t *testing.T) {
+	m := update(newStreamSelectModel[string](nil, "Select"),
+		page("dev-app / ReadOnly", "prod-app / ReadOnly", "prod-app / SysAdmin", "prod-data / Admin"),
+		typed("prod admin"), tea.KeyMsg{Type: tea.KeyEnter})
+	if m.selected == nil || *m.selected != "prod-data / Admin" {
+		t.Errorf("selected = %v, want best match prod-data / Admin", m.selected)
+	}
+}
+
+func TestSelectAccountRole(t *testing.T) {
+	pairs := []AccountRole{
+		{Account: types.AccountInfo{AccountName: ptr("prod-app"), AccountId: ptr("111111111111")}, Role: types.RoleInfo{RoleName: ptr("Admin")}},
+		{Account: types.AccountInfo{AccountName: ptr("dev-app"), AccountId: ptr("222222222222")}},
+		{Account: types.AccountInfo{AccountName: ptr("prod-app"), AccountId: ptr("111111111111")}, Role: types.RoleInfo{RoleName: ptr("ReadOnly")}},
+	}
+	labels := AccountLabels{Metadata: map[string]file.AccountMetadata{
+		"111111111111": {Aliases: []string{"payments"}, Environment: "prod", Color: "9"},
+	}}
+	var (
+		keys   []string
+		colors map[string]string
+	)
+	selector := func(pages <-chan OptionPage[AccountRole], title string) (AccountRole, error) {
+		page := <-pages
+		for _, option := range page.Options {
+			keys = append(keys, option.Key)
+		}
+		colors = page.Colors
+		return page.Options[0].Value, nil
+	}
+
+	selected, err := SelectAccountRole(pairs, labels, []string{"111111111111/ReadOnly"}, selector)
+	if err != nil {
+		t.Fatalf("SelectAccountRole() error = %v", err)
+	}
+	if selected.ID() != "111111111111/ReadOnly" {
+		t.Errorf("SelectAccountRole() = %s, want preferred 111111111111/ReadOnly", selected.ID())
+	}
+	want := []string{
+		"prod-app                       111111111111 [prod] aka payments /  / ReadOnly",
+		"dev-app                        222222222222 /  / (choose role)",
+		"prod-app                       111111111111 [prod] aka payments /  / Admin",
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("options = %q, want %q", keys, want)
+	}
+	if len(colors) != 2 || colors[want[0]] != "9" || colors[want[2]] != "9" {
+		t.Errorf("colors = %v, want the prod-app pairs colored 9", colors)
+	}
+}
@@ -24,3 +24,8 @@ var awsRegions = []string{
 	"me-south-1",
 	"sa-east-1",
 }
+
+// Regions returns the selectable regions
+func Regions() []string {
+	return append([]string{}, awsRegions...)
+}
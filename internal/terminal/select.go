@@ -2,9 +2,12 @@ package terminal
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/service/sso/types"
 	"github.com/charmbracelet/huh"
+
+	"ssoctx/internal/file"
 )
 
 // NewSelectForm creates a new select form
@@ -39,18 +42,44 @@ func GenerateGenericOptions[T comparable](items []T) []huh.Option[T] {
 	return options
 }
 
+// AccountLabels describes accounts in the pickers with the metadata from config
+type AccountLabels struct {
+	Metadata           map[string]file.AccountMetadata // metadata per account id
+	GroupByEnvironment bool                            // keys start with the environment, so sorting groups them
+}
+
 // generateAccountInfoOptions generates AccountInfo options
-func generateAccountInfoOptions(items []types.AccountInfo) []huh.Option[types.AccountInfo] {
+func generateAccountInfoOptions(items []types.AccountInfo, labels AccountLabels) []huh.Option[types.AccountInfo] {
 	options := make([]huh.Option[types.AccountInfo], len(items))
 	for i, item := range items {
 		options[i] = huh.Option[types.AccountInfo]{
-			Key:   fmt.Sprintf("%-30s %s", *item.AccountName, *item.AccountId),
+			Key:   accountKey(item, labels),
 			Value: item,
 		}
 	}
 	return options
 }
 
+// accountKey returns the name and id of the account followed by its environment, aliases and team
+func accountKey(account types.AccountInfo, labels AccountLabels) string {
+	metadata := labels.Metadata[*account.AccountId]
+	key := fmt.Sprintf("%-30s %s", *account.AccountName, *account.AccountId)
+	if len(metadata.Environment) > 0 {
+		if labels.GroupByEnvironment {
+			key = fmt.Sprintf("[%s] %s", metadata.Environment, key)
+		} else {
+			key = fmt.Sprintf("%s [%s]", key, metadata.Environment)
+		}
+	}
+	if len(metadata.Aliases) > 0 {
+		key = fmt.Sprintf("%s aka %s", key, strings.Join(metadata.Aliases, ", "))
+	}
+	if len(metadata.Team) > 0 {
+		key = fmt.Sprintf("%s team %s", key, metadata.Team)
+	}
+	return key
+}
+
 // generateRoleInfoOptions generates RoleInfo options
 func generateRoleInfoOptions(items []types.RoleInfo) []huh.Option[types.RoleInfo] {
 	options := make([]huh.Option[types.RoleInfo], len(items))
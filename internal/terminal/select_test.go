@@ -5,6 +5,8 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/service/sso/types"
 	"github.com/charmbracelet/huh"
+
+	"ssoctx/internal/file"
 )
 
 func TestGenerateGenericOptions(t *testing.T) {
@@ -87,11 +89,51 @@ func TestGenerateAccountInfoOptions(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			generateAccountInfoOptions(tt.input)
+			generateAccountInfoOptions(tt.input, AccountLabels{})
 		})
 	}
 }
 
+func TestAccountKey(t *testing.T) {
+	account := types.AccountInfo{AccountName: ptr("acme-7f3a"), AccountId: ptr("111111111111")}
+	metadata := map[string]file.AccountMetadata{
+		"111111111111": {Aliases: []string{"payments", "pay"}, Environment: "prod", Team: "billing"},
+	}
+	tests := []struct {
+		name   string
+		labels AccountLabels
+		want   string
+	}{
+		{"no metadata", AccountLabels{}, "acme-7f3a                      111111111111"},
+		{"metadata", AccountLabels{Metadata: metadata}, "acme-7f3a                      111111111111 [prod] aka payments, pay team billing"},
+		{"grouped", AccountLabels{Metadata: metadata, GroupByEnvironment: true}, "[prod] acme-7f3a                      111111111111 aka payments, pay team billing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := accountKey(account, tt.labels); got != tt.want {
+				t.Errorf("accountKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccountPage(t *testing.T) {
+	accounts := []types.AccountInfo{
+		{AccountName: ptr("prod"), AccountId: ptr("111111111111")},
+		{AccountName: ptr("dev"), AccountId: ptr("222222222222")},
+	}
+	labels := AccountLabels{Metadata: map[string]file.AccountMetadata{"111111111111": {Color: "9"}}}
+
+	page := AccountPage(accounts, labels)
+	if len(page.Options) != 2 {
+		t.Fatalf("AccountPage() options = %d, want 2", len(page.Options))
+	}
+	want := map[string]string{page.Options[0].Key: "9"}
+	if len(page.Colors) != 1 || page.Colors[page.Options[0].Key] != "9" {
+		t.Errorf("AccountPage() colors = %v, want %v", page.Colors, want)
+	}
+}
+
 func TestGenerateRoleInfoOptions(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -0,0 +1,329 @@
+package terminal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// streamSelectHeight is the number of options shown at once
+const streamSelectHeight = 10
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("212"))
+	cursorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("212"))
+	statusStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	selectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("212"))
+)
+
+// OptionPage is a page of options streamed into NewStreamSelectForm while it is open
+// Pinned options stay above the others in the order they are pinned, like favourites and recent picks.
+// Colors are lipgloss colors per option key.
+// Status is shown while loading, Err is shown when listing failed
+type OptionPage[T comparable] struct {
+	Options []huh.Option[T]
+	Pinned  bool
+	Colors  map[string]string
+	Status  string
+	Err     error
+}
+
+// StreamSelectorFunc is a generic function type for selection from streamed options
+type StreamSelectorFunc[T comparable] func(<-chan OptionPage[T], string) (T, error)
+
+// pagesDoneMsg is sent when the pages channel is closed
+type pagesDoneMsg struct{}
+
+// streamSelectModel is a filterable select that adds options as pages arrive
+type streamSelectModel[T comparable] struct {
+	title    string
+	pages    <-chan OptionPage[T]
+	options  []huh.Option[T]
+	pinned   map[string]int            // pinned order by option key
+	colors   map[string]lipgloss.Style // style by option key
+	filtered []int                     // indexes of options matching the filter
+	filter   textinput.Model
+	cursor   int
+	offset   int
+	height   int
+	loading  bool
+	status   string
+	err      error
+	selected *T
+	aborted  bool
+}
+
+// NewStreamSelectForm creates a select form that can be used before every page of options is listed
+// Options are sorted by key below the pinned options as they arrive and filtered fuzzily by typing
+func NewStreamSelectForm[T comparable](pages <-chan OptionPage[T], title string) (T, error) {
+	var output T
+
+	final, err := tea.NewProgram(newStreamSelectModel(pages, title)).Run()
+	if err != nil {
+		return output, err
+	}
+	model := final.(streamSelectModel[T])
+	if model.aborted || model.selected == nil {
+		return output, huh.ErrUserAborted
+	}
+	return *model.selected, nil
+}
+
+// AccountPage returns a page of AccountInfo options for NewStreamSelectForm, colored by the account metadata
+func AccountPage(accounts []types.AccountInfo, labels AccountLabels) OptionPage[types.AccountInfo] {
+	options := generateAccountInfoOptions(accounts, labels)
+	return OptionPage[types.AccountInfo]{
+		Options: options,
+		Colors:  accountColors(options, labels, func(account types.AccountInfo) types.AccountInfo { return account }),
+	}
+}
+
+// accountColors returns the color per option key of the options whose account has a color in the metadata
+func accountColors[T comparable](options []huh.Option[T], labels AccountLabels, account func(T) types.AccountInfo) map[string]string {
+	var colors map[string]string
+	for _, option := range options {
+		if color := labels.Metadata[aws.ToString(account(option.Value).AccountId)].Color; len(color) > 0 {
+			if colors == nil {
+				colors = map[string]string{}
+			}
+			colors[option.Key] = color
+		}
+	}
+	return colors
+}
+
+func newStreamSelectModel[T comparable](pages <-chan OptionPage[T], title string) streamSelectModel[T] {
+	filter := textinput.New()
+	filter.Prompt = "/ "
+	filter.Placeholder = "type to filter"
+	filter.Focus()
+	return streamSelectModel[T]{
+		title:   title,
+		pages:   pages,
+		pinned:  map[string]int{},
+		colors:  map[string]lipgloss.Style{},
+		filter:  filter,
+		height:  streamSelectHeight,
+		loading: true,
+	}
+}
+
+// waitForPage returns the next page or pagesDoneMsg when the channel is closed
+func waitForPage[T comparable](pages <-chan OptionPage[T]) tea.Cmd {
+	return func() tea.Msg {
+		page, ok := <-pages
+		if !ok {
+			return pagesDoneMsg{}
+		}
+		return page
+	}
+}
+
+func (m streamSelectModel[T]) Init() tea.Cmd {
+	return tea.Batch(textinput.Blink, waitForPage(m.pages))
+}
+
+func (m streamSelectModel[T]) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case OptionPage[T]:
+		if len(msg.Options) > 0 {
+			highlighted, ok := m.highlighted()
+			if msg.Pinned {
+				m.pin(msg.Options)
+			}
+			m.addOptions(msg.Options)
+			sort.SliceStable(m.options, m.less)
+			m.applyFilter()
+			if ok {
+				m.moveTo(highlighted)
+			}
+		}
+		for key, color := range msg.Colors {
+			m.colors[key] = lipgloss.NewStyle().Foreground(lipgloss.Color(color))
+		}
+		if len(msg.Status) > 0 {
+			m.status = msg.Status
+		}
+		if msg.Err != nil {
+			m.err = msg.Err
+		}
+		return m, waitForPage(m.pages)
+	case pagesDoneMsg:
+		m.loading = false
+		m.status = ""
+		return m, nil
+	case tea.WindowSizeMsg:
+		m.height = min(streamSelectHeight, max(1, msg.Height-5))
+		m.scroll()
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			m.aborted = true
+			return m, tea.Quit
+		case tea.KeyEnter:
+			if value, ok := m.highlighted(); ok {
+				m.selected = &value
+				return m, tea.Quit
+			}
+			return m, nil
+		case tea.KeyUp, tea.KeyCtrlP:
+			m.cursor--
+			m.scroll()
+			return m, nil
+		case tea.KeyDown, tea.KeyCtrlN:
+			m.cursor++
+			m.scroll()
+			return m, nil
+		case tea.KeyPgUp:
+			m.cursor -= m.height
+			m.scroll()
+			return m, nil
+		case tea.KeyPgDown:
+			m.cursor += m.height
+			m.scroll()
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	previous := m.filter.Value()
+	m.filter, cmd = m.filter.Update(msg)
+	if m.filter.Value() != previous {
+		m.applyFilter()
+		m.cursor = 0
+		m.scroll()
+	}
+	return m, cmd
+}
+
+func (m streamSelectModel[T]) View() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(m.title) + "\n")
+	b.WriteString(m.filter.View() + "\n")
+
+	end := min(m.offset+m.height, len(m.filtered))
+	for i := m.offset; i < end; i++ {
+		key := m.options[m.filtered[i]].Key
+		if i == m.cursor {
+			b.WriteString(cursorStyle.Render("> ") + selectedStyle.Render(key) + "\n")
+		} else {
+			b.WriteString("  " + m.render(key) + "\n")
+		}
+	}
+
+	count := fmt.Sprintf("%d/%d", len(m.filtered), len(m.options))
+	switch {
+	case m.err != nil:
+		b.WriteString(errorStyle.Render(fmt.Sprintf("%s, listing stopped: %v", count, m.err)))
+	case m.loading && len(m.status) > 0:
+		b.WriteString(statusStyle.Render(fmt.Sprintf("%s, loading more... %s", count, m.status)))
+	case m.loading:
+		b.WriteString(statusStyle.Render(count + ", loading more..."))
+	default:
+		b.WriteString(statusStyle.Render(count))
+	}
+	return b.String() + "\n"
+}
+
+// render styles the key with its color
+func (m streamSelectModel[T]) render(key string) string {
+	if style, ok := m.colors[key]; ok {
+		return style.Render(key)
+	}
+	return key
+}
+
+// addOptions adds the options whose key is not shown yet
+// Pages may repeat options, like cached options followed by the listed ones
+func (m *streamSelectModel[T]) addOptions(options []huh.Option[T]) {
+	keys := make(map[string]bool, len(m.options))
+	for _, option := range m.options {
+		keys[option.Key] = true
+	}
+	for _, option := range options {
+		if !keys[option.Key] {
+			keys[option.Key] = true
+			m.options = append(m.options, option)
+		}
+	}
+}
+
+// pin keeps the options above the others, after the options pinned before
+func (m *streamSelectModel[T]) pin(options []huh.Option[T]) {
+	for _, option := range options {
+		if _, ok := m.pinned[option.Key]; !ok {
+			m.pinned[option.Key] = len(m.pinned)
+		}
+	}
+}
+
+// less orders pinned options first in pinned order and the others by key
+func (m streamSelectModel[T]) less(i, j int) bool {
+	pi, iPinned := m.pinned[m.options[i].Key]
+	pj, jPinned := m.pinned[m.options[j].Key]
+	switch {
+	case iPinned && jPinned:
+		return pi < pj
+	case iPinned != jPinned:
+		return iPinned
+	default:
+		return m.options[i].Key < m.options[j].Key
+	}
+}
+
+// applyFilter keeps the options whose key fuzzily matches the filter, best matches first
+func (m *streamSelectModel[T]) applyFilter() {
+	scores := make(map[int]int, len(m.options))
+	m.filtered = m.filtered[:0]
+	for i, option := range m.options {
+		if score, ok := fuzzyScore(option.Key, m.filter.Value()); ok {
+			scores[i] = score
+			m.filtered = append(m.filtered, i)
+		}
+	}
+	sort.SliceStable(m.filtered, func(i, j int) bool {
+		return scores[m.filtered[i]] > scores[m.filtered[j]]
+	})
+	m.scroll()
+}
+
+// highlighted returns the value of the option under the cursor
+func (m streamSelectModel[T]) highlighted() (T, bool) {
+	var value T
+	if m.cursor < 0 || m.cursor >= len(m.filtered) {
+		return value, false
+	}
+	return m.options[m.filtered[m.cursor]].Value, true
+}
+
+// moveTo moves the cursor to the option with the value so new pages do not move the highlight
+func (m *streamSelectModel[T]) moveTo(value T) {
+	for i, index := range m.filtered {
+		if m.options[index].Value == value {
+			m.cursor = i
+			m.scroll()
+			return
+		}
+	}
+}
+
+// scroll keeps the cursor within the options and the visible window
+func (m *streamSelectModel[T]) scroll() {
+	m.cursor = max(0, min(m.cursor, len(m.filtered)-1))
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+m.height {
+		m.offset = m.cursor - m.height + 1
+	}
+	m.offset = max(0, min(m.offset, len(m.filtered)-m.height))
+}
@@ -0,0 +1,126 @@
+package terminal
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+)
+
+func update(m streamSelectModel[string], msgs ...tea.Msg) streamSelectModel[string] {
+	for _, msg := range msgs {
+		model, _ := m.Update(msg)
+		m = model.(streamSelectModel[string])
+	}
+	return m
+}
+
+func page(keys ...string) OptionPage[string] {
+	return OptionPage[string]{Options: GenerateGenericOptions(keys)}
+}
+
+func pinned(keys ...string) OptionPage[string] {
+	return OptionPage[string]{Options: GenerateGenericOptions(keys), Pinned: true}
+}
+
+func typed(text string) tea.Msg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(text)}
+}
+
+func TestStreamSelectModel(t *testing.T) {
+	tests := []struct {
+		name     string
+		msgs     []tea.Msg
+		want     string
+		selected bool
+		aborted  bool
+	}{
+		{
+			name:     "select before last page",
+			msgs:     []tea.Msg{page("bravo", "delta"), tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyEnter}},
+			want:     "delta",
+			selected: true,
+		},
+		{
+			name:     "highlight kept when pages arrive",
+			msgs:     []tea.Msg{page("bravo", "delta"), tea.KeyMsg{Type: tea.KeyDown}, page("alpha", "charlie"), tea.KeyMsg{Type: tea.KeyEnter}},
+			want:     "delta",
+			selected: true,
+		},
+		{
+			name:     "filter",
+			msgs:     []tea.Msg{page("alpha", "bravo"), page("charlie"), typed("AR"), tea.KeyMsg{Type: tea.KeyEnter}},
+			want:     "charlie",
+			selected: true,
+		},
+		{
+			name:     "repeated options",
+			msgs:     []tea.Msg{page("alpha"), page("alpha", "bravo"), tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyEnter}},
+			want:     "bravo",
+			selected: true,
+		},
+		{
+			name:     "pinned first",
+			msgs:     []tea.Msg{pinned("delta", "bravo"), page("alpha", "bravo", "charlie"), tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyEnter}},
+			want:     "bravo",
+			selected: true,
+		},
+		{
+			name: "no match",
+			msgs: []tea.Msg{page("alpha"), typed("zulu"), tea.KeyMsg{Type: tea.KeyEnter}},
+		},
+		{
+			name:    "abort",
+			msgs:    []tea.Msg{page("alpha"), tea.KeyMsg{Type: tea.KeyEsc}},
+			aborted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := update(newStreamSelectModel[string](nil, "Select"), tt.msgs...)
+			if (m.selected != nil) != tt.selected || (tt.selected && *m.selected != tt.want) {
+				t.Errorf("selected = %v, want %v", m.selected, tt.want)
+			}
+			if m.aborted != tt.aborted {
+				t.Errorf("aborted = %v, want %v", m.aborted, tt.aborted)
+			}
+		})
+	}
+}
+
+func TestStreamSelectModelView(t *testing.T) {
+	m := update(newStreamSelectModel[string](nil, "Select"),
+		page("alpha", "bravo"),
+		OptionPage[string]{Status: "ListAccounts throttled, retry 1 in 250ms"},
+	)
+	if view := m.View(); !strings.Contains(view, "2/2, loading more... ListAccounts throttled") {
+		t.Errorf("View() = %q, want loading status", view)
+	}
+
+	m = update(m, OptionPage[string]{Err: errors.New("access denied")}, pagesDoneMsg{})
+	if view := m.View(); !strings.Contains(view, "listing stopped: access denied") {
+		t.Errorf("View() = %q, want error", view)
+	}
+
+	m = update(newStreamSelectModel[string](nil, "Select"), OptionPage[string]{Options: []huh.Option[string]{huh.NewOption("one", "1")}}, pagesDoneMsg{})
+	if view := m.View(); !strings.Contains(view, "> ") || strings.Contains(view, "loading") {
+		t.Errorf("View() = %q, want loaded options", view)
+	}
+}
+
+func TestStreamSelectModelScroll(t *testing.T) {
+	keys := make([]string, 30)
+	for i := range keys {
+		keys[i] = string(rune('a'+i%26)) + strings.Repeat("z", i/26)
+	}
+	m := update(newStreamSelectModel[string](nil, "Select"), page(keys...), tea.KeyMsg{Type: tea.KeyPgDown}, tea.KeyMsg{Type: tea.KeyPgDown}, tea.KeyMsg{Type: tea.KeyPgDown}, tea.KeyMsg{Type: tea.KeyPgDown})
+	if m.cursor != 29 || m.offset != 20 {
+		t.Errorf("cursor = %d, offset = %d, want 29, 20", m.cursor, m.offset)
+	}
+	m = update(m, tea.KeyMsg{Type: tea.KeyUp})
+	if m.cursor != 28 || m.offset != 20 {
+		t.Errorf("cursor = %d, offset = %d, want 28, 20", m.cursor, m.offset)
+	}
+}